@@ -0,0 +1,40 @@
+package main
+
+import (
+	"djtracker/internal/config"
+	"fmt"
+	"log/slog"
+)
+
+const usage = `usage: trackker [command]
+
+Without command, starts the tracker and the HTTP server.
+
+Commands:
+  failures list [-all]        list history entries that could not be parsed
+  failures reprocess [-id N]  parse quarantined entries again and save them as tracks
+  search [-limit N] <query>   find when tracks matching the query were played
+  export [-format F] [-offset D] [-o file] <event id>
+                              write the event tracklist as csv, md, txt, json, youtube,
+                              mixcloud or cue, with times relative to the event start
+                              shifted by the recording offset
+  webhooks [-limit N]         list the latest webhook delivery attempts`
+
+// runCommand exécute une sous-commande de la ligne de commande
+func runCommand(conf *config.Config, log *slog.Logger, name string, args []string) error {
+	switch name {
+	case "failures":
+		return runFailures(conf, log, args)
+	case "search":
+		return runSearch(conf, log, args)
+	case "export":
+		return runExport(conf, log, args)
+	case "webhooks":
+		return runWebhooks(conf, log, args)
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", name, usage)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"database/sql"
+	"djtracker/internal/api/exporter"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"djtracker/internal/repository"
+	"djtracker/internal/utils"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+func runExport(conf *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "txt", "export format: csv, md, txt, json, youtube, mixcloud or cue")
+	offset := flags.Duration("offset", 0, "recording start, relative to the event start (e.g. 1h30m)")
+	output := flags.String("o", "", "output file, stdout when empty")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("missing event id\n%s", usage)
+	}
+	eventID, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid event id %q", flags.Arg(0))
+	}
+
+	exp, err := exporter.NewExporter(*format)
+	if err != nil {
+		return err
+	}
+
+	return database.UseDb(conf, func(db *sql.DB) error {
+		setlist, err := exporter.LoadSetlist(repository.New(log, db, conf.Database.Driver), eventID, *offset)
+		if err != nil {
+			return err
+		}
+		if setlist == nil {
+			return fmt.Errorf("event %d not found", eventID)
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			file, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer utils.SafeClose(file)
+			w = file
+		}
+		return exp.Export(w, setlist)
+	})
+}
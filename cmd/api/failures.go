@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"djtracker/internal/service/parser"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func runFailures(conf *config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing failures subcommand (list, reprocess)\n%s", usage)
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("failures list", flag.ExitOnError)
+		all := flags.Bool("all", false, "include entries already reprocessed")
+		_ = flags.Parse(args[1:])
+
+		return database.UseDb(conf, func(db *sql.DB) error {
+			return listFailures(repository.New(log, db, conf.Database.Driver), *all)
+		})
+	case "reprocess":
+		flags := flag.NewFlagSet("failures reprocess", flag.ExitOnError)
+		id := flags.Int64("id", 0, "only reprocess the entry with this id")
+		_ = flags.Parse(args[1:])
+
+		tracksParser, err := parser.GetParser(conf, log)
+		if err != nil {
+			return err
+		}
+
+		return database.UseDb(conf, func(db *sql.DB) error {
+			return reprocessFailures(repository.New(log, db, conf.Database.Driver), tracksParser, service.NewEnricher(log, nil), *id)
+		})
+	default:
+		return fmt.Errorf("unknown failures subcommand %q\n%s", args[0], usage)
+	}
+}
+
+func listFailures(repo repository.FailureStore, all bool) error {
+	failures, err := repo.FindParseFailures(all)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tEVENT\tKIND\tCREATED\tRESOLVED\tERROR\tRAW")
+	for _, f := range failures {
+		resolved := "-"
+		if f.ResolvedAt != nil {
+			resolved = f.ResolvedAt.Format(time.DateTime)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			f.ID, f.EventID, f.Kind, f.CreatedAt.Format(time.DateTime), resolved, f.Error, strings.ReplaceAll(f.Raw, "\n", " | "))
+	}
+	return w.Flush()
+}
+
+// reprocessFailures relance le parsing des entrées en quarantaine
+// et enregistre les tracks obtenues dans l'événement d'origine
+func reprocessFailures(repo repository.Store, tracksParser parser.Parser, enricher *service.Enricher, id int64) error {
+	var failures []*model.ParseFailure
+	if id != 0 {
+		failure, err := repo.FindParseFailure(id)
+		if err != nil {
+			return err
+		}
+		if failure == nil {
+			return fmt.Errorf("parse failure %d not found", id)
+		}
+		failures = append(failures, failure)
+	} else {
+		found, err := repo.FindParseFailures(false)
+		if err != nil {
+			return err
+		}
+		failures = found
+	}
+
+	recovered := 0
+	for _, failure := range failures {
+		if failure.ResolvedAt != nil {
+			fmt.Printf("#%d already reprocessed\n", failure.ID)
+			continue
+		}
+
+		track, err := tracksParser.Parse(failure.Raw)
+		if err != nil {
+			fmt.Printf("#%d still invalid: %s\n", failure.ID, err)
+			continue
+		}
+		enricher.Enrich(track)
+
+		if err := repo.AddTrackToEvent(failure.EventID, track); err != nil {
+			return err
+		}
+		if err := repo.ResolveParseFailure(failure.ID, time.Now()); err != nil {
+			return err
+		}
+		recovered++
+		fmt.Printf("#%d recovered as track %d (%s)\n", failure.ID, track.ID, track.Name)
+	}
+
+	fmt.Printf("%d/%d entries recovered\n", recovered, len(failures))
+	return nil
+}
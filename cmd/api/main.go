@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"djtracker/internal/api"
 	"djtracker/internal/api/formatter"
@@ -8,22 +9,43 @@ import (
 	"djtracker/internal/database"
 	"djtracker/internal/repository"
 	"djtracker/internal/service"
+	"djtracker/internal/service/library"
 	"djtracker/internal/service/parser"
+	"djtracker/internal/sink"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})
-	logger := slog.New(handler)
-
 	conf, err := config.New()
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if len(os.Args) > 1 {
+		// Les sous-commandes écrivent leur résultat sur stdout, les logs partent sur stderr
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+		}))
+		if err := runCommand(conf, logger, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})
+	serve(conf, slog.New(handler))
+}
+
+func serve(conf *config.Config, logger *slog.Logger) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	if err := conf.Check(); err != nil {
 		log.Fatal(err)
 	}
@@ -42,19 +64,46 @@ func main() {
 	}
 
 	err = database.UseDb(conf, func(db *sql.DB) error {
-		repo := repository.New(logger, db)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		repo := repository.New(logger, db, conf.Database.Driver)
 		if err := repo.PrepareEvent(); err != nil {
 			return err
 		}
 
-		tracker := service.NewTracker(logger, conf, repo, tracksParser)
-		tracker.StartTracking()
+		musicLibrary := library.New(logger, conf.Tracker.Source.Paths)
+		go func() {
+			if err := musicLibrary.Index(ctx); err != nil {
+				logger.Warn("Library indexing stopped", "err", err)
+			}
+		}()
+
+		enricher := service.NewEnricher(logger, musicLibrary)
+		tracker := service.NewTracker(logger, conf, repo, tracksParser, enricher)
+
+		sinks, err := sink.New(conf, logger, repo)
+		if err != nil {
+			return err
+		}
+		// Le tracker charge la track en cours avant le démarrage des sorties, qui la reçoivent au démarrage
+		tracker.StartTracking(ctx)
+		outputs := sink.NewDispatcher(logger, sinks)
+		outputs.Start(ctx, tracker)
+
+		requests := service.NewRequests(logger, repo, musicLibrary)
+		server := api.NewServer(conf, logger, tracker, repo, sseFormatter, requests)
+		err = server.Start(ctx)
 
-		server := api.NewServer(conf, logger, tracker, sseFormatter)
-		return server.Start()
+		// Arrêt de la lecture et enregistrement des dernières tracks avant la fermeture de la base
+		cancel()
+		tracker.Wait()
+		outputs.Wait()
+		return err
 	})
 
 	if err != nil {
 		log.Panicln(err)
 	}
+	logger.Info("Shutdown complete")
 }
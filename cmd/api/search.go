@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"djtracker/internal/repository"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func runSearch(conf *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	limit := flags.Int("limit", 20, "maximum number of results")
+	_ = flags.Parse(args)
+
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return fmt.Errorf("missing search query\n%s", usage)
+	}
+
+	return database.UseDb(conf, func(db *sql.DB) error {
+		results, err := repository.New(log, db, conf.Database.Driver).SearchTracks(query, *limit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "PLAYED\tEVENT\tARTIST\tTITLE\tREMIX\tPATH")
+		for _, result := range results {
+			t := result.Track
+			_, _ = fmt.Fprintf(w, "%s\t#%d (%s)\t%s\t%s\t%s\t%s\n",
+				t.PlayAt.Format(time.DateTime), t.EventID, result.EventStart.Format(time.DateOnly),
+				valueOrDash(t.Artist), t.Name, valueOrDash(t.Remix), t.Path)
+		}
+		return w.Flush()
+	})
+}
+
+func valueOrDash(value *string) string {
+	if value == nil {
+		return "-"
+	}
+	return *value
+}
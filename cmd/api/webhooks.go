@@ -0,0 +1,43 @@
+package main
+
+import (
+	"database/sql"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"djtracker/internal/repository"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func runWebhooks(conf *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("webhooks", flag.ExitOnError)
+	limit := flags.Int("limit", 50, "maximum number of deliveries")
+	_ = flags.Parse(args)
+
+	return database.UseDb(conf, func(db *sql.DB) error {
+		deliveries, err := repository.New(log, db, conf.Database.Driver).FindWebhookDeliveries(*limit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tSENT\tEVENT\tTRACK\tATTEMPT\tSTATUS\tWEBHOOK\tERROR")
+		for _, d := range deliveries {
+			track, status := "-", "-"
+			if d.TrackID != nil {
+				track = strconv.FormatInt(*d.TrackID, 10)
+			}
+			if d.StatusCode != nil {
+				status = strconv.Itoa(*d.StatusCode)
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				d.ID, d.CreatedAt.Format(time.DateTime), d.EventType, track, d.Attempt, status, d.Webhook, valueOrDash(d.Error))
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"djtracker/internal/metrics"
+	"djtracker/internal/utils"
+	"sync"
+
+	"github.com/dhowden/tag"
+)
+
+const (
+	coverCacheSize = 32
+	// coverInfoCacheSize nombre de fichiers dont le type et la taille de la pochette restent connus
+	// après l'éviction de l'image : les flux n'ont besoin que de ces informations
+	coverInfoCacheSize = 1024
+)
+
+// coverInfo type et taille de la pochette d'un fichier
+type coverInfo struct {
+	MIMEType string
+	Size     int
+}
+
+// coverCache garde en mémoire les pochettes des dernières tracks demandées
+// pour éviter de relire le fichier audio à chaque requête.
+// Les fichiers sans pochette sont aussi mémorisés (valeur nil).
+type coverCache struct {
+	mu       sync.Mutex
+	pictures *boundedCache[*tag.Picture]
+	infos    *boundedCache[*coverInfo]
+}
+
+func newCoverCache(size int) *coverCache {
+	return &coverCache{
+		pictures: newBoundedCache[*tag.Picture](size),
+		infos:    newBoundedCache[*coverInfo](max(size, coverInfoCacheSize)),
+	}
+}
+
+// Get retourne la pochette du fichier, nil si le fichier n'en contient pas
+func (c *coverCache) Get(path string) *tag.Picture {
+	c.mu.Lock()
+	cover, ok := c.pictures.get(path)
+	c.mu.Unlock()
+
+	if ok {
+		metrics.CoverCacheHits.Inc()
+		return cover
+	}
+	metrics.CoverCacheMisses.Inc()
+
+	cover = utils.GetTrackCover(path)
+
+	var info *coverInfo
+	if cover != nil {
+		info = &coverInfo{MIMEType: cover.MIMEType, Size: len(cover.Data)}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pictures.put(path, cover)
+	c.infos.put(path, info)
+	return cover
+}
+
+// Info retourne le type et la taille de la pochette du fichier, nil s'il n'en contient pas.
+// Le fichier n'est lu que s'il n'a encore jamais été demandé ou a quitté le cache des informations.
+func (c *coverCache) Info(path string) *coverInfo {
+	c.mu.Lock()
+	info, ok := c.infos.get(path)
+	c.mu.Unlock()
+
+	if ok {
+		metrics.CoverCacheHits.Inc()
+		return info
+	}
+
+	if cover := c.Get(path); cover != nil {
+		return &coverInfo{MIMEType: cover.MIMEType, Size: len(cover.Data)}
+	}
+	return nil
+}
+
+// boundedCache associe des valeurs à des clés dans la limite de size entrées,
+// les plus anciennes étant supprimées en premier. Non protégé contre les accès concurrents.
+type boundedCache[V any] struct {
+	size    int
+	order   []string
+	entries map[string]V
+}
+
+func newBoundedCache[V any](size int) *boundedCache[V] {
+	return &boundedCache[V]{
+		size:    size,
+		entries: make(map[string]V),
+	}
+}
+
+func (c *boundedCache[V]) get(key string) (V, bool) {
+	value, ok := c.entries[key]
+	return value, ok
+}
+
+func (c *boundedCache[V]) put(key string, value V) {
+	if _, ok := c.entries[key]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = value
+}
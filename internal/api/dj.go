@@ -0,0 +1,167 @@
+package api
+
+import (
+	"crypto/subtle"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/model"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requireDJ réserve le handler au DJ : le jeton configuré (server.dj_token) doit être fourni
+// dans l'en-tête Authorization (Bearer) ou le paramètre token, utilisable par EventSource
+func (s *Server) requireDJ(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := s.config.Server.DJToken
+		if expected == "" {
+			http.Error(w, "DJ access is disabled, set server.dj_token to enable it", http.StatusForbidden)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			http.Error(w, "invalid DJ token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) LoadDJPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "static/dj.html")
+	}
+}
+
+// ListenForDJSSE Flux réservé au DJ : tracks, réactions, corrections et avertissements (track déjà jouée)
+func (s *Server) ListenForDJSSE() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", eventStreamContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		defer s.countSSEClient(streamDJ)()
+
+		tracksChannel, unsubscribeTracks := s.tracker.SubscribeForTracks()
+		defer unsubscribeTracks()
+		warningsChannel, unsubscribeWarnings := s.tracker.SubscribeForWarnings()
+		defer unsubscribeWarnings()
+		correctionsChannel, unsubscribeCorrections := s.tracker.SubscribeForCorrections()
+		defer unsubscribeCorrections()
+		reactionsChannel, unsubscribeReactions := s.reactions.Subscribe()
+		defer unsubscribeReactions()
+
+		sseW := &Sse{w}
+		if current := s.tracker.GetCurrentTrack(); current != nil {
+			s.formatAndSendSse(sseW, current)
+			s.sendTrackReactions(sseW, current)
+		}
+
+		ping := time.NewTicker(1 * time.Second)
+		defer ping.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ping.C:
+				if _, err := sseW.Ping(); err != nil {
+					s.log.Error("Failed to send ping", "err", err)
+					continue
+				}
+				flusher.Flush()
+			case track, ok := <-tracksChannel:
+				if !ok {
+					if err := sseW.SendEvent("close", "shutdown"); err != nil {
+						s.log.Error("Failed to send close event", "err", err)
+					}
+					return
+				}
+				s.formatAndSendSse(sseW, track)
+				s.sendTrackReactions(sseW, track)
+			case warning, ok := <-warningsChannel:
+				if !ok {
+					// Fermeture simultanée avec le channel des tracks, l'événement close y est envoyé
+					warningsChannel = nil
+					continue
+				}
+				s.formatAndSendWarning(sseW, warning)
+			case track, ok := <-correctionsChannel:
+				if !ok {
+					correctionsChannel = nil
+					continue
+				}
+				s.formatAndSendCorrection(sseW, track)
+			case reactions, ok := <-reactionsChannel:
+				if !ok {
+					reactionsChannel = nil
+					continue
+				}
+				s.formatAndSendReactions(sseW, reactions)
+			}
+		}
+	}
+}
+
+func (s *Server) formatAndSendWarning(sseW *Sse, warning *model.RepeatWarning) {
+	response, err := s.formatter.FormatWarning(warning)
+	if err != nil {
+		s.log.Error("Failed to format warning", "err", err)
+		return
+	}
+
+	if err := sseW.SendEvent("warning", response); err != nil {
+		s.log.Error("Failed to send response", "err", err)
+	}
+}
+
+type checkDTO struct {
+	Path     string                  `json:"path"`
+	Played   bool                    `json:"played"`
+	Previous []*formatter.WarningDTO `json:"previous"`
+}
+
+// CheckTrack Indique si le fichier (?path=) a déjà été joué pendant l'événement en cours,
+// avec la liste des lectures correspondantes (même fichier ou même artiste et titre)
+func (s *Server) CheckTrack() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Query().Get("path")
+		if path == "" {
+			http.Error(w, "missing path parameter", http.StatusBadRequest)
+			return
+		}
+
+		// Les métadonnées sont lues depuis le fichier pour pouvoir comparer artiste et titre
+		track := &model.Track{Path: path}
+		s.tracker.Enrich(track)
+
+		warnings, err := s.tracker.FindPreviousPlays(track)
+		if err != nil {
+			s.log.Error("Failed to check track", "path", path, "err", err)
+			http.Error(w, "check failed", http.StatusInternalServerError)
+			return
+		}
+
+		response := &checkDTO{
+			Path:     path,
+			Played:   len(warnings) > 0,
+			Previous: make([]*formatter.WarningDTO, 0, len(warnings)),
+		}
+		for _, warning := range warnings {
+			response.Previous = append(response.Previous, formatter.NewWarningDTO(warning))
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write check response", "err", err)
+		}
+	}
+}
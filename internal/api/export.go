@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"djtracker/internal/api/exporter"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportEvent Tracklist d'un événement à publier (?format=csv|md|txt|json|youtube|mixcloud|cue, txt par défaut).
+// ?offset= décale l'origine des positions depuis le début de l'événement, par exemple au début
+// de l'enregistrement du set (durée Go : 1h30m, -15m...)
+func (s *Server) ExportEvent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid event id", http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "txt"
+		}
+		exp, err := exporter.NewExporter(format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var offset time.Duration
+		if value := r.URL.Query().Get("offset"); value != "" {
+			if offset, err = time.ParseDuration(value); err != nil {
+				http.Error(w, "invalid offset, expected a duration such as 1h30m", http.StatusBadRequest)
+				return
+			}
+		}
+
+		setlist, err := exporter.LoadSetlist(s.store, eventID, offset)
+		if err != nil {
+			s.log.Error("Failed to load setlist", "event", eventID, "err", err)
+			http.Error(w, "failed to export event", http.StatusInternalServerError)
+			return
+		}
+		if setlist == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Export complet en mémoire pour pouvoir répondre une erreur 500 en cas d'échec
+		var buf bytes.Buffer
+		if err := exp.Export(&buf, setlist); err != nil {
+			s.log.Error("Failed to export setlist", "event", eventID, "format", format, "err", err)
+			http.Error(w, "failed to export event", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", exp.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", exporter.FileName(setlist, exp)))
+		_, _ = w.Write(buf.Bytes())
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportEvent(t *testing.T) {
+	s := newTestServer(&config.Config{})
+	event := s.store.(*repository.Memory).AddEvent(time.Date(2024, 5, 18, 22, 0, 0, 0, time.UTC))
+	for _, track := range []*model.Track{
+		{Name: "One More Time", PlayAt: event.Start.Add(time.Minute)},
+		{Name: "Aerodynamic", PlayAt: event.Start.Add(6 * time.Minute)},
+	} {
+		if err := s.store.AddTrackToEvent(event.ID, track); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := s.routes()
+	path := fmt.Sprintf("/api/events/%d/export", event.ID)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"default format", path, http.StatusOK, "text/plain; charset=utf-8", "00:01:00  One More Time\n00:06:00  Aerodynamic\n"},
+		{"youtube", path + "?format=youtube", http.StatusOK, "text/plain; charset=utf-8", "00:00 One More Time\n06:00 Aerodynamic\n"},
+		{"offset", path + "?format=youtube&offset=5m", http.StatusOK, "text/plain; charset=utf-8", "00:00 One More Time\n01:00 Aerodynamic\n"},
+		{"unknown format", path + "?format=pdf", http.StatusBadRequest, "", "unsupported export format: pdf"},
+		{"invalid offset", path + "?offset=90", http.StatusBadRequest, "", "invalid offset"},
+		{"invalid event id", "/api/events/latest/export", http.StatusBadRequest, "", "invalid event id"},
+		{"unknown event", fmt.Sprintf("/api/events/%d/export", event.ID+1), http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				if !strings.Contains(rec.Body.String(), tt.body) {
+					t.Errorf("body = %q, want %q", rec.Body, tt.body)
+				}
+				return
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "setlist-2024-05-18.txt") {
+				t.Errorf("Content-Disposition = %q, want the setlist file name", got)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body =\n%s\nwant\n%s", rec.Body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type CsvExporter struct{}
+
+func (e *CsvExporter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *CsvExporter) Extension() string {
+	return "csv"
+}
+
+func (e *CsvExporter) Export(w io.Writer, setlist *Setlist) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "artist", "title", "remix"}); err != nil {
+		return err
+	}
+
+	for _, entry := range setlist.Entries {
+		err := writer.Write([]string{
+			formatOffset(entry.Offset),
+			valueOrEmpty(entry.Track.Artist),
+			entry.Track.Name,
+			valueOrEmpty(entry.Track.Remix),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
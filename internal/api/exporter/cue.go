@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// cueFramesPerSecond nombre de frames par seconde des positions INDEX d'une CUE sheet
+const cueFramesPerSecond = 75
+
+// CueExporter écrit une CUE sheet découpant l'enregistrement du set selon les heures de lecture
+type CueExporter struct{}
+
+func (e *CueExporter) ContentType() string {
+	return "application/x-cue; charset=utf-8"
+}
+
+func (e *CueExporter) Extension() string {
+	return "cue"
+}
+
+func (e *CueExporter) Export(w io.Writer, setlist *Setlist) error {
+	var b strings.Builder
+	date := setlist.Event.Start.Format(time.DateOnly)
+	fmt.Fprintf(&b, "REM DATE %s\n", date)
+	fmt.Fprintf(&b, "TITLE %s\n", cueString("Setlist du "+date))
+	fmt.Fprintf(&b, "FILE %s MP3\n", cueString("setlist-"+date+".mp3"))
+
+	for i, entry := range setlist.Entries {
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n", i+1)
+
+		title := entry.Track.Name
+		if entry.Track.Remix != nil {
+			title += " (" + *entry.Track.Remix + ")"
+		}
+		fmt.Fprintf(&b, "    TITLE %s\n", cueString(title))
+		if entry.Track.Artist != nil {
+			fmt.Fprintf(&b, "    PERFORMER %s\n", cueString(*entry.Track.Artist))
+		}
+		fmt.Fprintf(&b, "    INDEX 01 %s\n", formatCueIndex(entry.Offset))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatCueIndex formate une position au format MM:SS:FF, les minutes pouvant dépasser 59
+func formatCueIndex(offset time.Duration) string {
+	frames := int(offset.Seconds() * cueFramesPerSecond)
+	seconds := frames / cueFramesPerSecond
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/60, seconds%60, frames%cueFramesPerSecond)
+}
+
+// cueString met une valeur entre guillemets, les guillemets n'étant pas échappables dans une CUE sheet
+func cueString(value string) string {
+	return `"` + strings.ReplaceAll(singleLine(value), `"`, "'") + `"`
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Exporter écrit la tracklist d'un événement dans un format publiable
+type Exporter interface {
+	Export(w io.Writer, setlist *Setlist) error
+	ContentType() string
+	Extension() string
+}
+
+// NewExporter retourne l'exporter du format demandé (csv, md, txt, json, youtube, mixcloud ou cue)
+func NewExporter(format string) (Exporter, error) {
+	switch format {
+	case "csv":
+		return &CsvExporter{}, nil
+	case "md", "markdown":
+		return &MarkdownExporter{}, nil
+	case "txt", "text":
+		return &TextExporter{}, nil
+	case "json":
+		return &JsonExporter{}, nil
+	case "youtube":
+		return &YoutubeExporter{}, nil
+	case "mixcloud":
+		return &MixcloudExporter{}, nil
+	case "cue":
+		return &CueExporter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// Entry track de la tracklist, avec sa position depuis le début de la tracklist
+type Entry struct {
+	Track  *model.Track
+	Offset time.Duration
+}
+
+// Setlist tracklist d'un événement, sans les tracks passées (voir model.Track.Skipped)
+type Setlist struct {
+	Event *model.Event
+	// Start origine des positions : début de l'événement, ou début de l'enregistrement du set
+	Start   time.Time
+	Entries []Entry
+}
+
+// NewSetlist construit la tracklist de l'événement à partir de ses tracks triées par heure de lecture.
+// Les positions sont calculées depuis le début de l'événement décalé de offset (début de l'enregistrement) :
+// des tracks lancées avant, seule celle encore en cours à l'origine est gardée, en position 0.
+func NewSetlist(event *model.Event, tracks []*model.Track, offset time.Duration) *Setlist {
+	setlist := &Setlist{Event: event, Start: event.Start.Add(offset)}
+	for _, track := range tracks {
+		if track.Skipped {
+			continue
+		}
+
+		entry := Entry{Track: track, Offset: max(track.PlayAt.Sub(setlist.Start), 0)}
+		if n := len(setlist.Entries); entry.Offset == 0 && n > 0 && setlist.Entries[n-1].Offset == 0 {
+			setlist.Entries[n-1] = entry
+			continue
+		}
+		setlist.Entries = append(setlist.Entries, entry)
+	}
+	return setlist
+}
+
+// LoadSetlist charge la tracklist d'un événement (voir NewSetlist), nil s'il n'existe pas
+func LoadSetlist(store repository.Store, eventID int64, offset time.Duration) (*Setlist, error) {
+	event, err := store.FindEvent(eventID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	tracks, err := store.FindEventTracks(eventID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSetlist(event, tracks, offset), nil
+}
+
+// FileName nom du fichier d'export de la tracklist, par exemple setlist-2024-05-18.csv
+func FileName(setlist *Setlist, exporter Exporter) string {
+	return fmt.Sprintf("setlist-%s.%s", setlist.Event.Start.Format(time.DateOnly), exporter.Extension())
+}
+
+// formatOffset formate une position au format HH:MM:SS
+func formatOffset(offset time.Duration) string {
+	hours, minutes, seconds := splitOffset(offset)
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
+
+// TrackTitle retourne « Artiste - Titre (Remix) », sans les parties inconnues
+func TrackTitle(track *model.Track) string {
+	title := track.Name
+	if track.Artist != nil {
+		title = *track.Artist + " - " + title
+	}
+	if track.Remix != nil {
+		title += " (" + *track.Remix + ")"
+	}
+	return title
+}
+
+// splitOffset découpe une position en heures, minutes et secondes
+func splitOffset(offset time.Duration) (hours, minutes, seconds int) {
+	total := int(offset.Seconds())
+	return total / 3600, total / 60 % 60, total % 60
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
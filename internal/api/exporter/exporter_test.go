@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"djtracker/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var eventStart = time.Date(2024, 5, 18, 22, 0, 0, 0, time.UTC)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// track jouée offset après le début de l'événement
+func track(name string, offset time.Duration) *model.Track {
+	return &model.Track{Name: name, PlayAt: eventStart.Add(offset)}
+}
+
+// entries résume les entrées d'une tracklist, une par ligne au format « HH:MM:SS titre »
+func entries(setlist *Setlist) string {
+	var b strings.Builder
+	for _, entry := range setlist.Entries {
+		b.WriteString(formatOffset(entry.Offset) + " " + entry.Track.Name + "\n")
+	}
+	return b.String()
+}
+
+func TestNewSetlist(t *testing.T) {
+	skipped := track("Genesis", 3*time.Minute+10*time.Second)
+	skipped.Skipped = true
+	tracks := []*model.Track{
+		track("Da Funk", -10*time.Minute),
+		track("Around the World", -4*time.Minute),
+		track("One More Time", 3*time.Minute),
+		skipped,
+		track("Aerodynamic", 8*time.Minute),
+		track("Digital Love", 75*time.Minute),
+	}
+
+	tests := []struct {
+		name   string
+		tracks []*model.Track
+		offset time.Duration
+		want   string
+	}{
+		{
+			name: "empty event",
+		},
+		{
+			// Seule la dernière track lancée avant le début est gardée, la track passée est ignorée
+			name:   "tracks before start",
+			tracks: tracks,
+			want: "00:00:00 Around the World\n" +
+				"00:03:00 One More Time\n" +
+				"00:08:00 Aerodynamic\n" +
+				"01:15:00 Digital Love\n",
+		},
+		{
+			// Enregistrement commencé 5 minutes après l'événement : One More Time est en cours
+			name:   "recording offset",
+			tracks: tracks,
+			offset: 5 * time.Minute,
+			want: "00:00:00 One More Time\n" +
+				"00:03:00 Aerodynamic\n" +
+				"01:10:00 Digital Love\n",
+		},
+		{
+			name:   "track at recording start",
+			tracks: tracks,
+			offset: 8 * time.Minute,
+			want: "00:00:00 Aerodynamic\n" +
+				"01:07:00 Digital Love\n",
+		},
+	}
+
+	event := &model.Event{ID: 1, Start: eventStart}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setlist := NewSetlist(event, tt.tracks, tt.offset)
+			if !setlist.Start.Equal(eventStart.Add(tt.offset)) {
+				t.Errorf("Start = %s, want %s", setlist.Start, eventStart.Add(tt.offset))
+			}
+			if got := entries(setlist); got != tt.want {
+				t.Errorf("entries =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCueIndex(t *testing.T) {
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, "00:00:00"},
+		{40 * time.Millisecond, "00:00:03"},
+		{1500 * time.Millisecond, "00:01:37"},
+		{59*time.Second + 990*time.Millisecond, "00:59:74"},
+		{3*time.Minute + 25*time.Second, "03:25:00"},
+		// Pas d'heures dans une CUE sheet : les minutes dépassent 59
+		{75 * time.Minute, "75:00:00"},
+		{2*time.Hour + 5*time.Second, "120:05:00"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCueIndex(tt.offset); got != tt.want {
+			t.Errorf("formatCueIndex(%s) = %s, want %s", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestCueExporter(t *testing.T) {
+	first := track("One More Time", 0)
+	first.Artist = ptr("Daft Punk")
+	second := track(`Say "Hello"`, 4*time.Minute+30*time.Second)
+	second.Artist = ptr("The \"Chemical\"\nBrothers")
+	second.Remix = ptr("Club\tMix")
+	third := track("Untitled\r\nTrack", 90*time.Minute)
+
+	setlist := NewSetlist(&model.Event{ID: 1, Start: eventStart}, []*model.Track{first, second, third}, 0)
+
+	var b strings.Builder
+	if err := (&CueExporter{}).Export(&b, setlist); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `REM DATE 2024-05-18
+TITLE "Setlist du 2024-05-18"
+FILE "setlist-2024-05-18.mp3" MP3
+  TRACK 01 AUDIO
+    TITLE "One More Time"
+    PERFORMER "Daft Punk"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Say 'Hello' (Club Mix)"
+    PERFORMER "The 'Chemical' Brothers"
+    INDEX 01 04:30:00
+  TRACK 03 AUDIO
+    TITLE "Untitled Track"
+    INDEX 01 90:00:00
+`
+	if got := b.String(); got != want {
+		t.Errorf("Export() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"djtracker/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormats(t *testing.T) {
+	opening := track("Around the World", -4*time.Minute)
+	opening.Artist = ptr("Daft Punk")
+	escaped := track("*Untitled* [Dub] #2", 3*time.Minute+20*time.Second)
+	escaped.Artist = ptr("DJ_Shadow")
+	escaped.Remix = ptr("`Live`\nEdit")
+	late := track("Digital Love", 75*time.Minute+5*time.Second)
+
+	tests := []struct {
+		format string
+		tracks []*model.Track
+		offset time.Duration
+		want   string
+	}{
+		{
+			// Première track lancée 30 secondes après l'origine : chapitre forcé à 00:00
+			format: "youtube",
+			tracks: []*model.Track{track("One More Time", 30*time.Second), track("Aerodynamic", 5*time.Minute)},
+			want: "00:00 One More Time\n" +
+				"05:00 Aerodynamic\n",
+		},
+		{
+			// Plus d'une heure : toutes les positions avec les heures
+			format: "youtube",
+			tracks: []*model.Track{opening, escaped, late},
+			want: "0:00:00 Daft Punk - Around the World\n" +
+				"0:03:20 DJ_Shadow - *Untitled* [Dub] #2 (`Live` Edit)\n" +
+				"1:15:05 Digital Love\n",
+		},
+		{
+			// Origine décalée : moins d'une heure de set, positions sans les heures
+			format: "youtube",
+			tracks: []*model.Track{opening, escaped, late},
+			offset: 20 * time.Minute,
+			want: "00:00 DJ_Shadow - *Untitled* [Dub] #2 (`Live` Edit)\n" +
+				"55:05 Digital Love\n",
+		},
+		{
+			format: "mixcloud",
+			tracks: []*model.Track{opening, escaped, late},
+			want: "sections-0-artist=Daft Punk\n" +
+				"sections-0-song=Around the World\n" +
+				"sections-0-start_time=0\n" +
+				"sections-1-artist=DJ_Shadow\n" +
+				"sections-1-song=*Untitled* [Dub] #2 (`Live` Edit)\n" +
+				"sections-1-start_time=200\n" +
+				"sections-2-artist=\n" +
+				"sections-2-song=Digital Love\n" +
+				"sections-2-start_time=4505\n",
+		},
+		{
+			format: "mixcloud",
+			tracks: []*model.Track{opening, escaped, late},
+			offset: 2 * time.Minute,
+			want: "sections-0-artist=Daft Punk\n" +
+				"sections-0-song=Around the World\n" +
+				"sections-0-start_time=0\n" +
+				"sections-1-artist=DJ_Shadow\n" +
+				"sections-1-song=*Untitled* [Dub] #2 (`Live` Edit)\n" +
+				"sections-1-start_time=80\n" +
+				"sections-2-artist=\n" +
+				"sections-2-song=Digital Love\n" +
+				"sections-2-start_time=4385\n",
+		},
+		{
+			format: "markdown",
+			tracks: []*model.Track{opening, escaped, late},
+			want: "# Setlist du 2024-05-18\n\n" +
+				"1. `00:00:00` Daft Punk - Around the World\n" +
+				"2. `00:03:20` DJ\\_Shadow - \\*Untitled\\* \\[Dub\\] \\#2 (\\`Live\\`\nEdit)\n" +
+				"3. `01:15:05` Digital Love\n",
+		},
+		{
+			format: "md",
+			want:   "# Setlist du 2024-05-18\n\n",
+		},
+	}
+
+	event := &model.Event{ID: 1, Start: eventStart}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			exporter, err := NewExporter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var b strings.Builder
+			if err := exporter.Export(&b, NewSetlist(event, tt.tracks, tt.offset)); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Export() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewExporterUnknownFormat(t *testing.T) {
+	if exporter, err := NewExporter("pdf"); err == nil || exporter != nil {
+		t.Errorf("NewExporter(pdf) = %v, %v, want an error", exporter, err)
+	}
+}
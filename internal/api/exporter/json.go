@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type setlistDTO struct {
+	EventID    int64      `json:"event_id"`
+	EventStart string     `json:"event_start"`
+	Tracks     []entryDTO `json:"tracks"`
+}
+
+type entryDTO struct {
+	Time          string  `json:"time"`
+	OffsetSeconds int     `json:"offset_seconds"`
+	Artist        *string `json:"artist"`
+	Title         string  `json:"title"`
+	Remix         *string `json:"remix"`
+}
+
+type JsonExporter struct{}
+
+func (e *JsonExporter) ContentType() string {
+	return "application/json"
+}
+
+func (e *JsonExporter) Extension() string {
+	return "json"
+}
+
+func (e *JsonExporter) Export(w io.Writer, setlist *Setlist) error {
+	dto := &setlistDTO{
+		EventID:    setlist.Event.ID,
+		EventStart: setlist.Event.Start.Format(time.RFC3339),
+		Tracks:     make([]entryDTO, 0, len(setlist.Entries)),
+	}
+	for _, entry := range setlist.Entries {
+		dto.Tracks = append(dto.Tracks, entryDTO{
+			Time:          formatOffset(entry.Offset),
+			OffsetSeconds: int(entry.Offset.Seconds()),
+			Artist:        entry.Track.Artist,
+			Title:         entry.Track.Name,
+			Remix:         entry.Track.Remix,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dto)
+}
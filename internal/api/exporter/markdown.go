@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// markdownEscaper protège les caractères interprétés par Markdown dans les titres
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, "#", `\#`,
+)
+
+type MarkdownExporter struct{}
+
+func (e *MarkdownExporter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (e *MarkdownExporter) Extension() string {
+	return "md"
+}
+
+func (e *MarkdownExporter) Export(w io.Writer, setlist *Setlist) error {
+	if _, err := fmt.Fprintf(w, "# Setlist du %s\n\n", setlist.Event.Start.Format(time.DateOnly)); err != nil {
+		return err
+	}
+
+	for i, entry := range setlist.Entries {
+		_, err := fmt.Fprintf(w, "%d. `%s` %s\n", i+1, formatOffset(entry.Offset), markdownEscaper.Replace(TrackTitle(entry.Track)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MixcloudExporter écrit la tracklist sous forme des champs sections-N-* de l'API d'upload Mixcloud,
+// un champ par ligne au format nom=valeur (utilisable avec curl -F)
+type MixcloudExporter struct{}
+
+func (e *MixcloudExporter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *MixcloudExporter) Extension() string {
+	return "txt"
+}
+
+func (e *MixcloudExporter) Export(w io.Writer, setlist *Setlist) error {
+	for i, entry := range setlist.Entries {
+		song := entry.Track.Name
+		if entry.Track.Remix != nil {
+			song += " (" + *entry.Track.Remix + ")"
+		}
+
+		fields := [][2]string{
+			{"artist", valueOrEmpty(entry.Track.Artist)},
+			{"song", song},
+			{"start_time", fmt.Sprint(int(entry.Offset.Seconds()))},
+		}
+		for _, field := range fields {
+			if _, err := fmt.Fprintf(w, "sections-%d-%s=%s\n", i, field[0], singleLine(field[1])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
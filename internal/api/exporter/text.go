@@ -0,0 +1,25 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+)
+
+type TextExporter struct{}
+
+func (e *TextExporter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *TextExporter) Extension() string {
+	return "txt"
+}
+
+func (e *TextExporter) Export(w io.Writer, setlist *Setlist) error {
+	for _, entry := range setlist.Entries {
+		if _, err := fmt.Fprintf(w, "%s  %s\n", formatOffset(entry.Offset), TrackTitle(entry.Track)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// YoutubeExporter écrit les chapitres à coller dans la description d'une vidéo YouTube.
+// YouTube impose un premier chapitre à 00:00 : la première track y est toujours placée.
+// Un chapitre par ligne : les titres sont ramenés sur une seule ligne.
+type YoutubeExporter struct{}
+
+func (e *YoutubeExporter) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *YoutubeExporter) Extension() string {
+	return "txt"
+}
+
+func (e *YoutubeExporter) Export(w io.Writer, setlist *Setlist) error {
+	withHours := len(setlist.Entries) > 0 && setlist.Entries[len(setlist.Entries)-1].Offset >= time.Hour
+
+	for i, entry := range setlist.Entries {
+		offset := entry.Offset
+		if i == 0 {
+			offset = 0
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", formatChapter(offset, withHours), singleLine(TrackTitle(entry.Track))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatChapter formate une position au format MM:SS, ou H:MM:SS pour les vidéos de plus d'une heure
+func formatChapter(offset time.Duration, withHours bool) string {
+	hours, minutes, seconds := splitOffset(offset)
+	if withHours {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
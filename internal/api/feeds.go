@@ -0,0 +1,386 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"djtracker/internal/api/exporter"
+	"djtracker/internal/model"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	feedTitle = "Trackker"
+	// feedTrackCount reste sous coverInfoCacheSize pour que les pochettes des entrées restent en cache
+	feedTrackCount = 30
+	feedEventCount = 10
+	// feedCoverAttempts nombre de tracks d'un événement essayées pour trouver la pochette de l'entrée
+	feedCoverAttempts = 3
+)
+
+// feedCover pochette d'une track exposée en pièce jointe d'une entrée de flux
+type feedCover struct {
+	URL      string
+	MIMEType string
+	Size     int
+}
+
+// baseURL retourne l'adresse publique du serveur (server.public_url), déduite de la requête à défaut
+func (s *Server) baseURL(r *http.Request) string {
+	if s.config.Server.PublicURL != "" {
+		return strings.TrimSuffix(s.config.Server.PublicURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedID identifiant permanent d'une entrée de flux (tag URI, RFC 4151)
+func feedID(base string, start time.Time, kind string, id int64) string {
+	host := base
+	if u, err := url.Parse(base); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s/%d", host, start.Format(time.DateOnly), kind, id)
+}
+
+func (s *Server) trackCover(base string, track *model.Track) *feedCover {
+	if track.Path == "" {
+		return nil
+	}
+	cover := s.covers.Info(track.Path)
+	if cover == nil {
+		return nil
+	}
+	return &feedCover{
+		URL:      fmt.Sprintf("%s/cover/%d", base, track.ID),
+		MIMEType: cover.MIMEType,
+		Size:     cover.Size,
+	}
+}
+
+// serveFeed envoie le flux avec un ETag calculé sur son contenu et sa date de dernière modification :
+// les requêtes conditionnelles (If-None-Match, If-Modified-Since) reçoivent une réponse 304 si le flux n'a pas changé.
+// modified doit tenir compte des tracks passées, qui retirent une entrée du flux (voir FindLastTrackChange).
+func serveFeed(w http.ResponseWriter, r *http.Request, contentType string, modified time.Time, body []byte) {
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", modified, bytes.NewReader(body))
+}
+
+// lastTrackChange retourne la date de la dernière modification de l'historique, répond une erreur 500 en cas d'échec
+func (s *Server) lastTrackChange(w http.ResponseWriter) (time.Time, bool) {
+	modified, err := s.store.FindLastTrackChange()
+	if err != nil {
+		s.log.Error("Failed to load last history change", "err", err)
+		http.Error(w, "failed to load feed", http.StatusInternalServerError)
+		return time.Time{}, false
+	}
+	return modified, true
+}
+
+func (s *Server) loadFeedTracks(w http.ResponseWriter) ([]*model.Track, time.Time, bool) {
+	tracks, err := s.store.FindRecentTracks(feedTrackCount)
+	if err != nil {
+		s.log.Error("Failed to load recent tracks", "err", err)
+		http.Error(w, "failed to load feed", http.StatusInternalServerError)
+		return nil, time.Time{}, false
+	}
+
+	modified, ok := s.lastTrackChange(w)
+	return tracks, modified, ok
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int    `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+	Links   []atomLink  `xml:"link"`
+}
+
+// GetTracksAtom Flux Atom des dernières tracks jouées
+func (s *Server) GetTracksAtom() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracks, modified, ok := s.loadFeedTracks(w)
+		if !ok {
+			return
+		}
+
+		base := s.baseURL(r)
+		feed := &atomFeed{
+			Title:   feedTitle + " - Dernières tracks",
+			ID:      base + "/feed/tracks.atom",
+			Updated: modified.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: feedTitle},
+			Links: []atomLink{
+				{Rel: "self", Href: base + "/feed/tracks.atom", Type: "application/atom+xml"},
+				{Rel: "alternate", Href: base + "/", Type: "text/html"},
+			},
+		}
+		for _, track := range tracks {
+			entry := atomEntry{
+				Title:   exporter.TrackTitle(track),
+				ID:      feedID(base, track.PlayAt, "track", track.ID),
+				Updated: track.PlayAt.UTC().Format(time.RFC3339),
+				Summary: trackFeedSummary(track),
+				Links: []atomLink{
+					{Rel: "alternate", Href: fmt.Sprintf("%s/api/events/%d/export", base, track.EventID), Type: "text/plain"},
+				},
+			}
+			if track.Artist != nil {
+				entry.Author = &atomAuthor{Name: *track.Artist}
+			}
+			if cover := s.trackCover(base, track); cover != nil {
+				entry.Links = append(entry.Links, atomLink{Rel: "enclosure", Href: cover.URL, Type: cover.MIMEType, Length: cover.Size})
+			}
+			feed.Entries = append(feed.Entries, entry)
+		}
+
+		body, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			s.log.Error("Failed to encode atom feed", "err", err)
+			http.Error(w, "failed to encode feed", http.StatusInternalServerError)
+			return
+		}
+		serveFeed(w, r, "application/atom+xml; charset=utf-8", modified, append([]byte(xml.Header), body...))
+	}
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// GetEventsRSS Flux RSS des derniers événements avec leur tracklist
+func (s *Server) GetEventsRSS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		events, err := s.store.FindRecentEvents(feedEventCount)
+		if err != nil {
+			s.log.Error("Failed to load recent events", "err", err)
+			http.Error(w, "failed to load feed", http.StatusInternalServerError)
+			return
+		}
+
+		modified, ok := s.lastTrackChange(w)
+		if !ok {
+			return
+		}
+
+		base := s.baseURL(r)
+		var items []rssItem
+		for _, event := range events {
+			tracks, err := s.store.FindEventTracks(event.ID)
+			if err != nil {
+				s.log.Error("Failed to load event tracks", "event", event.ID, "err", err)
+				http.Error(w, "failed to load feed", http.StatusInternalServerError)
+				return
+			}
+			setlist := exporter.NewSetlist(event, tracks, 0)
+
+			modified = latest(modified, event.Start)
+
+			link := fmt.Sprintf("%s/api/events/%d/export", base, event.ID)
+			item := rssItem{
+				Title:       fmt.Sprintf("Setlist du %s", event.Start.Format(time.DateOnly)),
+				Link:        link,
+				GUID:        rssGUID{Value: feedID(base, event.Start, "event", event.ID)},
+				PubDate:     event.Start.Format(time.RFC1123Z),
+				Description: setlistFeedDescription(setlist),
+			}
+			for i := 0; i < len(setlist.Entries) && i < feedCoverAttempts; i++ {
+				if cover := s.trackCover(base, setlist.Entries[i].Track); cover != nil {
+					item.Enclosure = &rssEnclosure{URL: cover.URL, Length: cover.Size, Type: cover.MIMEType}
+					break
+				}
+			}
+			items = append(items, item)
+		}
+
+		feed := &rssFeed{
+			Version: "2.0",
+			Channel: rssChannel{
+				Title:       feedTitle + " - Événements",
+				Link:        base + "/",
+				Description: "Tracklists des derniers événements",
+				Items:       items,
+			},
+		}
+		if !modified.IsZero() {
+			feed.Channel.LastBuildDate = modified.Format(time.RFC1123Z)
+		}
+
+		body, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			s.log.Error("Failed to encode rss feed", "err", err)
+			http.Error(w, "failed to encode feed", http.StatusInternalServerError)
+			return
+		}
+		serveFeed(w, r, "application/rss+xml; charset=utf-8", modified, append([]byte(xml.Header), body...))
+	}
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	ContentText   string               `json:"content_text"`
+	DatePublished string               `json:"date_published"`
+	Image         string               `json:"image,omitempty"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MIMEType    string `json:"mime_type"`
+	SizeInBytes int    `json:"size_in_bytes,omitempty"`
+}
+
+// GetTracksJSONFeed Flux des dernières tracks jouées au format JSON Feed 1.1
+func (s *Server) GetTracksJSONFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracks, modified, ok := s.loadFeedTracks(w)
+		if !ok {
+			return
+		}
+
+		base := s.baseURL(r)
+		feed := &jsonFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       feedTitle + " - Dernières tracks",
+			HomePageURL: base + "/",
+			FeedURL:     base + "/feed/tracks.json",
+			Items:       make([]jsonFeedItem, 0, len(tracks)),
+		}
+		for _, track := range tracks {
+			item := jsonFeedItem{
+				ID:            feedID(base, track.PlayAt, "track", track.ID),
+				URL:           fmt.Sprintf("%s/api/events/%d/export", base, track.EventID),
+				Title:         exporter.TrackTitle(track),
+				ContentText:   trackFeedSummary(track),
+				DatePublished: track.PlayAt.UTC().Format(time.RFC3339),
+			}
+			if track.Artist != nil {
+				item.Authors = []jsonFeedAuthor{{Name: *track.Artist}}
+			}
+			if cover := s.trackCover(base, track); cover != nil {
+				item.Image = cover.URL
+				item.Attachments = []jsonFeedAttachment{{URL: cover.URL, MIMEType: cover.MIMEType, SizeInBytes: cover.Size}}
+			}
+			feed.Items = append(feed.Items, item)
+		}
+
+		body, err := json.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			s.log.Error("Failed to encode json feed", "err", err)
+			http.Error(w, "failed to encode feed", http.StatusInternalServerError)
+			return
+		}
+		serveFeed(w, r, "application/feed+json; charset=utf-8", modified, body)
+	}
+}
+
+func trackFeedSummary(track *model.Track) string {
+	summary := "Jouée le " + track.PlayAt.Format("02/01/2006 à 15:04")
+	if track.Album != nil {
+		summary += ", album " + *track.Album
+	}
+	return summary
+}
+
+// setlistFeedDescription tracklist en HTML, échappée par l'encodage XML du flux
+func setlistFeedDescription(setlist *exporter.Setlist) string {
+	var b strings.Builder
+	b.WriteString("<ol>")
+	for _, entry := range setlist.Entries {
+		hours, minutes := int(entry.Offset.Hours()), int(entry.Offset.Minutes())%60
+		fmt.Fprintf(&b, "<li>%02d:%02d %s</li>", hours, minutes, html.EscapeString(exporter.TrackTitle(entry.Track)))
+	}
+	b.WriteString("</ol>")
+	return b.String()
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
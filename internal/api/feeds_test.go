@@ -0,0 +1,123 @@
+package api
+
+import (
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCoverMP3 écrit un fichier contenant uniquement un tag ID3v2.3 avec une pochette PNG
+func writeCoverMP3(t *testing.T, path string, picture []byte) {
+	t.Helper()
+	apic := append([]byte{0}, "image/png\x00"...)
+	apic = append(apic, 3, 0)
+	apic = append(apic, picture...)
+
+	frame := append([]byte("APIC"), binary.BigEndian.AppendUint32(nil, uint32(len(apic)))...)
+	frame = append(frame, 0, 0)
+	frame = append(frame, apic...)
+
+	size := len(frame)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	if err := os.WriteFile(path, append(header, frame...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getFeed(t *testing.T, handler http.Handler, header, value string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/feed/tracks.json", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Une track passée retire une entrée sans changer la date de la dernière track :
+// l'ETag et la date de modification du flux doivent tous deux changer
+func TestFeedIsRevalidatedAfterSkip(t *testing.T) {
+	s := newTestServer(&config.Config{})
+	if err := s.store.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Hour)
+	first := &model.Track{Name: "One More Time", PlayAt: start}
+	second := &model.Track{Name: "Aerodynamic", PlayAt: start.Add(10 * time.Second)}
+	third := &model.Track{Name: "Digital Love", PlayAt: start.Add(5 * time.Minute)}
+	for _, track := range []*model.Track{first, second, third} {
+		if err := s.store.AddTrackToHistory(track); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := s.routes()
+
+	rec := getFeed(t, handler, "", "")
+	etag, modified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	if rec.Code != http.StatusOK || etag == "" || modified != third.PlayAt.UTC().Format(http.TimeFormat) {
+		t.Fatalf("GET = %d, ETag %q, Last-Modified %q, want the last track date", rec.Code, etag, modified)
+	}
+	if rec := getFeed(t, handler, "If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("unchanged feed with If-None-Match = %d, want 304", rec.Code)
+	}
+	if rec := getFeed(t, handler, "If-Modified-Since", modified); rec.Code != http.StatusNotModified {
+		t.Fatalf("unchanged feed with If-Modified-Since = %d, want 304", rec.Code)
+	}
+
+	if err := s.store.MarkTrackSkipped(second.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = getFeed(t, handler, "If-None-Match", etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("feed after skip with If-None-Match = %d, ETag %q, want 200 and a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+	if strings.Contains(rec.Body.String(), "Aerodynamic") {
+		t.Error("skipped track still in feed")
+	}
+	if got := rec.Header().Get("Last-Modified"); got == modified {
+		t.Errorf("Last-Modified = %q, want the skip date", got)
+	}
+	if rec := getFeed(t, handler, "If-Modified-Since", modified); rec.Code != http.StatusOK {
+		t.Errorf("feed after skip with If-Modified-Since = %d, want 200", rec.Code)
+	}
+}
+
+// Le type et la taille de la pochette restent connus après l'éviction de l'image,
+// sans relire le fichier
+func TestCoverCacheKeepsInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cover.mp3")
+	picture := []byte("\x89PNG\r\n\x1a\nfake picture")
+	writeCoverMP3(t, path, picture)
+
+	covers := newCoverCache(1)
+	cover := covers.Get(path)
+	if cover == nil || cover.MIMEType != "image/png" {
+		t.Fatalf("Get() = %+v, want the png picture", cover)
+	}
+
+	// Fichier supprimé et image évincée par une autre pochette
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if covers.Get(filepath.Join(dir, "missing.mp3")) != nil {
+		t.Fatal("Get(missing) returned a picture")
+	}
+
+	info := covers.Info(path)
+	if info == nil || info.MIMEType != "image/png" || info.Size != len(picture) {
+		t.Errorf("Info() = %+v, want image/png of %d bytes", info, len(picture))
+	}
+	if covers.Info(filepath.Join(dir, "missing.mp3")) != nil {
+		t.Error("Info(missing) returned a cover")
+	}
+}
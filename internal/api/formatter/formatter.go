@@ -9,6 +9,14 @@ import (
 
 type Formatter interface {
 	Format(track *model.Track) (string, error)
+	FormatWarning(warning *model.RepeatWarning) (string, error)
+	// FormatCorrection formate une track déjà diffusée puis marquée comme passée
+	FormatCorrection(track *model.Track) (string, error)
+	// FormatReactions formate les compteurs de réactions du public sur une track
+	FormatReactions(reactions *model.Reactions) (string, error)
+	// FormatHistory formate une track à ajouter aux listes des dernières tracks (/history, /events/{id}),
+	// ou à en retirer si elle a été passée
+	FormatHistory(track *model.Track) (string, error)
 }
 
 func NewFormatter(cfg *config.Config, log *slog.Logger) (Formatter, error) {
@@ -20,7 +28,7 @@ func NewFormatter(cfg *config.Config, log *slog.Logger) (Formatter, error) {
 		log.Info("Unrecognized formatter value. Default html formatter will be used")
 	}
 
-	tmpl, err := template.ParseFiles("templates/current.html")
+	tmpl, err := template.ParseFiles("templates/current.html", "templates/warning.html", "templates/correction.html", "templates/reactions.html", "templates/history.html")
 	if err != nil {
 		return nil, err
 	}
@@ -14,8 +14,29 @@ type HtmlFormatter struct {
 }
 
 func (p *HtmlFormatter) Format(track *model.Track) (string, error) {
+	return p.execute("current.html", track)
+}
+
+func (p *HtmlFormatter) FormatWarning(warning *model.RepeatWarning) (string, error) {
+	return p.execute("warning.html", warning)
+}
+
+func (p *HtmlFormatter) FormatCorrection(track *model.Track) (string, error) {
+	return p.execute("correction.html", track)
+}
+
+func (p *HtmlFormatter) FormatReactions(reactions *model.Reactions) (string, error) {
+	return p.execute("reactions.html", reactions)
+}
+
+func (p *HtmlFormatter) FormatHistory(track *model.Track) (string, error) {
+	return p.execute("history.html", track)
+}
+
+// execute rend le template sur une seule ligne, comme l'impose le champ data d'un événement SSE
+func (p *HtmlFormatter) execute(name string, data any) (string, error) {
 	var buf bytes.Buffer
-	err := p.tmpl.Execute(&buf, track)
+	err := p.tmpl.ExecuteTemplate(&buf, name, data)
 	if err != nil {
 		return "", err
 	}
@@ -6,31 +6,102 @@ import (
 	"time"
 )
 
-type trackDTO struct {
-	ID       int64         `json:"id"`
-	Artist   *string       `json:"artist,omitempty"`
-	Name     string        `json:"name"`
-	PlayAt   string        `json:"play_at"`
-	Duration time.Duration `json:"duration"`
+// TrackDTO est la représentation JSON d'une track, partagée par le flux SSE et l'API
+type TrackDTO struct {
+	ID       int64             `json:"id"`
+	EventID  int64             `json:"event_id,omitempty"`
+	Artist   *string           `json:"artist,omitempty"`
+	Name     string            `json:"name"`
+	PlayAt   string            `json:"play_at"`
+	Duration time.Duration     `json:"duration"`
+	Remix    *string           `json:"remix,omitempty"`
+	Album    *string           `json:"album,omitempty"`
+	Genre    *string           `json:"genre,omitempty"`
+	Key      *string           `json:"key,omitempty"`
+	BPM      *float64          `json:"bpm,omitempty"`
+	Year     *int              `json:"year,omitempty"`
+	Deck     *int              `json:"deck,omitempty"`
+	FileSize *int64            `json:"file_size,omitempty"`
+	Sources  map[string]string `json:"sources,omitempty"`
+	RepeatOf *int64            `json:"repeat_of,omitempty"`
+	Skipped  bool              `json:"skipped,omitempty"`
 }
 
-func newTrackDTO(t *model.Track) *trackDTO {
-	return &trackDTO{
+func NewTrackDTO(t *model.Track) *TrackDTO {
+	return &TrackDTO{
 		ID:       t.ID,
+		EventID:  t.EventID,
 		Artist:   t.Artist,
 		Name:     t.Name,
 		PlayAt:   t.PlayAt.Format(time.RFC3339),
 		Duration: t.Duration,
+		Remix:    t.Remix,
+		Album:    t.Album,
+		Genre:    t.Genre,
+		Key:      t.Key,
+		BPM:      t.BPM,
+		Year:     t.Year,
+		Deck:     t.Deck,
+		FileSize: t.FileSize,
+		Sources:  t.Sources,
+		RepeatOf: t.RepeatOf,
+		Skipped:  t.Skipped,
 	}
 }
 
 type JsonFormatter struct{}
 
 func (p *JsonFormatter) Format(track *model.Track) (string, error) {
-	dto := newTrackDTO(track)
+	dto := NewTrackDTO(track)
 	data, err := json.Marshal(dto)
 	if err != nil {
 		return "", err
 	}
 	return string(data), nil
 }
+
+// FormatCorrection la track corrigée porte déjà l'indicateur skipped
+func (p *JsonFormatter) FormatCorrection(track *model.Track) (string, error) {
+	return p.Format(track)
+}
+
+func (p *JsonFormatter) FormatHistory(track *model.Track) (string, error) {
+	return p.Format(track)
+}
+
+// WarningDTO est la représentation JSON d'un avertissement de track déjà jouée
+type WarningDTO struct {
+	Track     *TrackDTO `json:"track"`
+	Previous  *TrackDTO `json:"previous"`
+	MatchedBy string    `json:"matched_by"`
+}
+
+func NewWarningDTO(w *model.RepeatWarning) *WarningDTO {
+	return &WarningDTO{
+		Track:     NewTrackDTO(w.Track),
+		Previous:  NewTrackDTO(w.Previous),
+		MatchedBy: w.MatchedBy,
+	}
+}
+
+func (p *JsonFormatter) FormatWarning(warning *model.RepeatWarning) (string, error) {
+	data, err := json.Marshal(NewWarningDTO(warning))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ReactionsDTO est la représentation JSON des réactions du public sur une track
+type ReactionsDTO struct {
+	TrackID int64 `json:"track_id"`
+	Likes   int   `json:"likes"`
+}
+
+func (p *JsonFormatter) FormatReactions(reactions *model.Reactions) (string, error) {
+	data, err := json.Marshal(&ReactionsDTO{TrackID: reactions.TrackID, Likes: reactions.Likes})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
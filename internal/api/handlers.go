@@ -2,8 +2,8 @@ package api
 
 import (
 	"djtracker/internal/model"
-	"djtracker/internal/utils"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -13,15 +13,30 @@ func (s *Server) LoadIndex() http.HandlerFunc {
 	}
 }
 
+// GetCover Pochette de la track demandée (/cover/{id}), de la track en cours sans identifiant
 func (s *Server) GetCover() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		current := s.tracker.GetCurrentTrack()
-		if current == nil {
+		track := s.tracker.GetCurrentTrack()
+		if id := r.PathValue("id"); id != "" {
+			trackID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid track id", http.StatusBadRequest)
+				return
+			}
+			if track == nil || track.ID != trackID {
+				if track, err = s.store.FindTrack(trackID); err != nil {
+					s.log.Error("Failed to find track", "track", trackID, "err", err)
+					http.Error(w, "failed to load cover", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+		if track == nil {
 			http.NotFound(w, r)
 			return
 		}
 
-		cover := utils.GetTrackCover(current.Path)
+		cover := s.covers.Get(track.Path)
 		if cover == nil {
 			http.NotFound(w, r)
 			return
@@ -35,36 +50,125 @@ func (s *Server) GetCover() http.HandlerFunc {
 
 func (s *Server) ListenForTracksSSE() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Type", eventStreamContentType)
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 
 		flusher, ok := w.(http.Flusher)
 		if !ok {
 			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
 		}
+		defer s.countSSEClient(streamPublic)()
 
 		tracksChannel, unsubscribe := s.tracker.SubscribeForTracks()
 		defer unsubscribe()
+		correctionsChannel, unsubscribeCorrections := s.tracker.SubscribeForCorrections()
+		defer unsubscribeCorrections()
+		reactionsChannel, unsubscribeReactions := s.reactions.Subscribe()
+		defer unsubscribeReactions()
 
 		sseW := &Sse{w}
 		if current := s.tracker.GetCurrentTrack(); current != nil {
 			s.formatAndSendSse(sseW, current)
+			s.sendTrackReactions(sseW, current)
 		}
 
 		ping := time.NewTicker(1 * time.Second)
+		defer ping.Stop()
 		for {
 			select {
 			case <-r.Context().Done():
 				return
 			case <-ping.C:
 				if _, err := sseW.Ping(); err != nil {
-					s.log.Error("Failed to send ping", err)
+					s.log.Error("Failed to send ping", "err", err)
 					continue
 				}
 				flusher.Flush()
-			case track := <-tracksChannel:
+			case track, ok := <-tracksChannel:
+				if !ok {
+					// Arrêt du serveur : on prévient le client avant de fermer le flux
+					if err := sseW.SendEvent("close", "shutdown"); err != nil {
+						s.log.Error("Failed to send close event", "err", err)
+					}
+					return
+				}
 				s.formatAndSendSse(sseW, track)
+				s.sendTrackReactions(sseW, track)
+			case track, ok := <-correctionsChannel:
+				if !ok {
+					// Fermeture simultanée avec le channel des tracks, l'événement close y est envoyé
+					correctionsChannel = nil
+					continue
+				}
+				s.formatAndSendCorrection(sseW, track)
+			case reactions, ok := <-reactionsChannel:
+				if !ok {
+					reactionsChannel = nil
+					continue
+				}
+				s.formatAndSendReactions(sseW, reactions)
+			}
+		}
+	}
+}
+
+// ListenForHistorySSE Flux des listes de dernières tracks (/history, /events/{id}) : chaque nouvelle track
+// est ajoutée à la liste, une track passée en est retirée (voir templates/history.html)
+func (s *Server) ListenForHistorySSE() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", eventStreamContentType)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		defer s.countSSEClient(streamHistory)()
+
+		tracksChannel, unsubscribe := s.tracker.SubscribeForTracks()
+		defer unsubscribe()
+		correctionsChannel, unsubscribeCorrections := s.tracker.SubscribeForCorrections()
+		defer unsubscribeCorrections()
+
+		sseW := &Sse{w}
+		// Ouverture immédiate du flux : la page affiche déjà les tracks jouées
+		if _, err := sseW.Ping(); err != nil {
+			s.log.Error("Failed to send ping", "err", err)
+			return
+		}
+		flusher.Flush()
+
+		ping := time.NewTicker(1 * time.Second)
+		defer ping.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ping.C:
+				if _, err := sseW.Ping(); err != nil {
+					s.log.Error("Failed to send ping", "err", err)
+					continue
+				}
+				flusher.Flush()
+			case track, ok := <-tracksChannel:
+				if !ok {
+					if err := sseW.SendEvent("close", "shutdown"); err != nil {
+						s.log.Error("Failed to send close event", "err", err)
+					}
+					return
+				}
+				s.formatAndSendHistory(sseW, track)
+			case track, ok := <-correctionsChannel:
+				if !ok {
+					correctionsChannel = nil
+					continue
+				}
+				// La track corrigée porte l'indicateur Skipped : le template la retire de la liste
+				s.formatAndSendHistory(sseW, track)
 			}
 		}
 	}
@@ -73,11 +177,57 @@ func (s *Server) ListenForTracksSSE() http.HandlerFunc {
 func (s *Server) formatAndSendSse(sseW *Sse, track *model.Track) {
 	response, err := s.formatter.Format(track)
 	if err != nil {
-		s.log.Error("Failed to format cover data", err)
+		s.log.Error("Failed to format cover data", "err", err)
 		return
 	}
 
 	if err := sseW.SendEvent("track", response); err != nil {
-		s.log.Error("Failed to send response", err)
+		s.log.Error("Failed to send response", "err", err)
+	}
+}
+
+func (s *Server) formatAndSendHistory(sseW *Sse, track *model.Track) {
+	response, err := s.formatter.FormatHistory(track)
+	if err != nil {
+		s.log.Error("Failed to format history", "err", err)
+		return
+	}
+
+	if err := sseW.SendEvent("history", response); err != nil {
+		s.log.Error("Failed to send response", "err", err)
+	}
+}
+
+func (s *Server) formatAndSendCorrection(sseW *Sse, track *model.Track) {
+	response, err := s.formatter.FormatCorrection(track)
+	if err != nil {
+		s.log.Error("Failed to format correction", "err", err)
+		return
+	}
+
+	if err := sseW.SendEvent("correction", response); err != nil {
+		s.log.Error("Failed to send response", "err", err)
+	}
+}
+
+// sendTrackReactions envoie les compteurs de la track, remis à zéro à chaque nouvelle track
+func (s *Server) sendTrackReactions(sseW *Sse, track *model.Track) {
+	reactions, err := s.reactions.Count(track)
+	if err != nil {
+		s.log.Error("Failed to count reactions", "track", track.ID, "err", err)
+		return
+	}
+	s.formatAndSendReactions(sseW, reactions)
+}
+
+func (s *Server) formatAndSendReactions(sseW *Sse, reactions *model.Reactions) {
+	response, err := s.formatter.FormatReactions(reactions)
+	if err != nil {
+		s.log.Error("Failed to format reactions", "err", err)
+		return
+	}
+
+	if err := sseW.SendEvent("reactions", response); err != nil {
+		s.log.Error("Failed to send response", "err", err)
 	}
 }
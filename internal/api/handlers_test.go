@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"djtracker/internal/service/parser"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanParser transmet au tracker les tracks envoyées sur son channel
+type chanParser struct {
+	tracks chan *model.Track
+}
+
+func (p *chanParser) CheckState() error   { return nil }
+func (p *chanParser) State() parser.State { return parser.State{} }
+
+func (p *chanParser) Parse(string) (*model.Track, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *chanParser) WithHistoryTrackReader(fn func(reader *bufio.Reader) error) error {
+	return fn(nil)
+}
+
+func (p *chanParser) StartHistoryTracking(ctx context.Context, _ *bufio.Reader, ch chan *model.Track, _ chan *model.ParseFailure) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case track := <-p.tracks:
+			ch <- track
+		}
+	}
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+// streamEvents se connecte au flux SSE et transmet ses événements jusqu'à la fin du test
+func streamEvents(t *testing.T, client *http.Client, url string) chan sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && event.name != "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent retourne le prochain événement du type demandé, en comptant les autres dans seen
+func nextEvent(t *testing.T, events chan sseEvent, name string, seen map[string]int) sseEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed before a %s event", name)
+			}
+			if event.name == name {
+				return event
+			}
+			if seen != nil {
+				seen[event.name]++
+			}
+		case <-timeout:
+			t.Fatalf("no %s event received", name)
+		}
+	}
+}
+
+// Le flux de l'historique ajoute les nouvelles tracks et retire les tracks passées ;
+// le flux de la page d'accueil ne reçoit pas ces événements
+func TestHistoryStream(t *testing.T) {
+	// Templates chargés depuis la racine du dépôt, comme au lancement du serveur
+	t.Chdir("../..")
+
+	conf := &config.Config{}
+	conf.Tracker.MinPlayTime = 30
+	html, err := formatter.NewFormatter(conf, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := repository.NewMemory()
+	if err := store.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	p := &chanParser{tracks: make(chan *model.Track)}
+	tracker := service.NewTracker(discardLogger(), conf, store, p, service.NewEnricher(discardLogger(), nil))
+	s := NewServer(conf, discardLogger(), tracker, store, html, nil)
+
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	// Arrêt du tracker avant celui du serveur : la fermeture des abonnements termine les flux
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker.StartTracking(ctx)
+	defer tracker.Wait()
+	defer cancel()
+	history := streamEvents(t, server.Client(), server.URL+"/history/events")
+	index := streamEvents(t, server.Client(), server.URL+"/events")
+	waitFor(t, "both SSE clients to connect", func() bool {
+		return s.sseClients.Load() == 2
+	})
+
+	start := time.Now().Add(-time.Minute)
+	first := &model.Track{Name: "One More Time", Path: "/music/one-more-time.mp3", PlayAt: start, Duration: 5 * time.Minute}
+	p.tracks <- first
+
+	added := nextEvent(t, history, "history", nil)
+	if !strings.Contains(added.data, fmt.Sprintf(`id="history-track-%d"`, first.ID)) || !strings.Contains(added.data, "One More Time") {
+		t.Errorf("history event = %s, want the new track", added.data)
+	}
+
+	// Remplacée au bout de 10 secondes : passée
+	p.tracks <- &model.Track{Name: "Aerodynamic", Path: "/music/aerodynamic.mp3", PlayAt: start.Add(10 * time.Second), Duration: 4 * time.Minute}
+
+	var removed, next bool
+	for range 2 {
+		event := nextEvent(t, history, "history", nil)
+		switch {
+		case strings.Contains(event.data, `hx-swap-oob="delete"`):
+			removed = strings.Contains(event.data, fmt.Sprintf(`id="history-track-%d"`, first.ID))
+		case strings.Contains(event.data, "Aerodynamic"):
+			next = true
+		}
+	}
+	if !removed || !next {
+		t.Errorf("history events: skipped track removed %v, next track added %v", removed, next)
+	}
+
+	seen := map[string]int{}
+	nextEvent(t, index, "track", seen)
+	nextEvent(t, index, "correction", seen)
+	if seen["history"] > 0 {
+		t.Errorf("index stream received %d history events", seen["history"])
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+type databaseHealthDTO struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type parserHealthDTO struct {
+	Running      bool    `json:"running"`
+	File         string  `json:"file,omitempty"`
+	LastReadAt   *string `json:"last_read_at,omitempty"`
+	LastError    string  `json:"last_error,omitempty"`
+	LastErrorAt  *string `json:"last_error_at,omitempty"`
+	Restarts     int     `json:"restarts"`
+	PersistError string  `json:"persist_error,omitempty"`
+}
+
+type healthDTO struct {
+	Status      string             `json:"status"`
+	Database    *databaseHealthDTO `json:"database,omitempty"`
+	Parser      *parserHealthDTO   `json:"parser,omitempty"`
+	LastTrackAt *string            `json:"last_track_at,omitempty"`
+	Subscribers int                `json:"subscribers"`
+}
+
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+// Live Indique seulement que le processus répond aux requêtes
+func (s *Server) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeHealth(w, http.StatusOK, &healthDTO{
+			Status:      "ok",
+			Subscribers: int(s.sseClients.Load()),
+		})
+	}
+}
+
+// Ready Vérifie la base de données et l'ingestion de l'historique.
+// Retourne 503 si l'une des deux est en échec.
+func (s *Server) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+
+		ready := true
+		dbHealth := &databaseHealthDTO{OK: true}
+		if err := s.tracker.CheckDatabase(ctx); err != nil {
+			ready = false
+			dbHealth.OK = false
+			dbHealth.Error = err.Error()
+		}
+
+		status, parserState := s.tracker.Status()
+		parserHealth := &parserHealthDTO{
+			Running:     status.ReaderRunning,
+			File:        parserState.File,
+			LastReadAt:  formatOptionalTime(parserState.LastReadAt),
+			LastErrorAt: formatOptionalTime(status.LastErrorAt),
+			Restarts:    status.Restarts,
+		}
+		if status.LastError != nil {
+			parserHealth.LastError = status.LastError.Error()
+		}
+		if status.PersistError != nil {
+			parserHealth.PersistError = status.PersistError.Error()
+			ready = false
+		}
+		if !status.ReaderRunning {
+			ready = false
+		}
+
+		health := &healthDTO{
+			Status:      "ready",
+			Database:    dbHealth,
+			Parser:      parserHealth,
+			Subscribers: int(s.sseClients.Load()),
+		}
+		if last := s.tracker.LastTrack(); last != nil {
+			health.LastTrackAt = formatOptionalTime(last.PlayAt)
+		}
+
+		code := http.StatusOK
+		if !ready {
+			health.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+		s.writeHealth(w, code, health)
+	}
+}
+
+func (s *Server) writeHealth(w http.ResponseWriter, code int, health *healthDTO) {
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := writeJSON(w, code, health); err != nil {
+		s.log.Error("Failed to write health response", "err", err)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getReadiness(t *testing.T, handler http.Handler) (int, *healthDTO, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var health healthDTO
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatalf("invalid readiness body %q: %v", rec.Body, err)
+	}
+	return rec.Code, &health, rec.Body.String()
+}
+
+func TestReadiness(t *testing.T) {
+	conf := &config.Config{}
+	store := repository.NewMemory()
+	p := &chanParser{tracks: make(chan *model.Track)}
+	tracker := service.NewTracker(discardLogger(), conf, store, p, service.NewEnricher(discardLogger(), nil))
+	handler := NewServer(conf, discardLogger(), tracker, store, &formatter.JsonFormatter{}, nil).routes()
+
+	readerRunning := func() bool {
+		status, _ := tracker.Status()
+		return status.ReaderRunning
+	}
+
+	// Historique pas encore ouvert
+	if code, health, _ := getReadiness(t, handler); code != http.StatusServiceUnavailable || health.Status != "not_ready" || health.Parser.Running {
+		t.Errorf("before tracking = %d %+v, want 503 not_ready", code, health)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker.StartTracking(ctx)
+	defer tracker.Wait()
+	defer cancel()
+	waitFor(t, "history reader to start", readerRunning)
+
+	code, health, body := getReadiness(t, handler)
+	if code != http.StatusOK || health.Status != "ready" || !health.Database.OK || !health.Parser.Running {
+		t.Errorf("reader running = %d %s, want 200 ready", code, body)
+	}
+	// Contenu de l'historique non exposé sur ce point d'accès sans authentification
+	if strings.Contains(body, "last_line") {
+		t.Errorf("readiness body exposes the last history line: %s", body)
+	}
+
+	// Aucun événement préparé : la track ne peut pas être enregistrée
+	p.tracks <- &model.Track{Name: "One More Time", PlayAt: time.Now()}
+	waitFor(t, "persist error", func() bool {
+		status, _ := tracker.Status()
+		return status.PersistError != nil
+	})
+	if code, health, body := getReadiness(t, handler); code != http.StatusServiceUnavailable || health.Parser.PersistError == "" {
+		t.Errorf("after persist error = %d %s, want 503 with the error", code, body)
+	}
+
+	// L'enregistrement suivant réussit : de nouveau prêt
+	if err := store.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	p.tracks <- &model.Track{Name: "Aerodynamic", PlayAt: time.Now()}
+	waitFor(t, "persist error to clear", func() bool {
+		status, _ := tracker.Status()
+		return status.PersistError == nil
+	})
+	if code, health, body := getReadiness(t, handler); code != http.StatusOK || health.LastTrackAt == nil {
+		t.Errorf("after successful persist = %d %s, want 200 with the last track", code, body)
+	}
+
+	cancel()
+	waitFor(t, "history reader to stop", func() bool { return !readerRunning() })
+	if code, _, body := getReadiness(t, handler); code != http.StatusServiceUnavailable {
+		t.Errorf("reader stopped = %d %s, want 503", code, body)
+	}
+}
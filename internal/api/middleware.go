@@ -0,0 +1,27 @@
+package api
+
+import (
+	"djtracker/internal/metrics"
+	"net/http"
+	"time"
+)
+
+// instrument mesure la durée de chaque requête, regroupée par route déclarée sur le mux.
+// Les flux SSE restent ouverts pendant toute la connexion du client : leur durée n'est pas une latence,
+// ils sont comptés par le gauge sse_subscribers (voir Server.countSSEClient).
+func instrument(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		mux.ServeHTTP(w, r)
+
+		if w.Header().Get("Content-Type") == eventStreamContentType {
+			return
+		}
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
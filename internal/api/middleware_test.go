@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/metrics"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestServer(conf *config.Config) *Server {
+	store := repository.NewMemory()
+	tracker := service.NewTracker(discardLogger(), conf, store, nil, nil)
+	return NewServer(conf, discardLogger(), tracker, store, &formatter.JsonFormatter{}, nil)
+}
+
+// findMetric retourne la série de la métrique portant les libellés donnés, nil si elle n'existe pas
+func findMetric(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value != label.GetValue() {
+					continue series
+				}
+			}
+			return metric
+		}
+	}
+	return nil
+}
+
+func requestCount(t *testing.T, route string) uint64 {
+	t.Helper()
+	metric := findMetric(t, "trackker_http_request_duration_seconds", map[string]string{"route": route, "method": http.MethodGet})
+	if metric == nil {
+		return 0
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func sseSubscribers(t *testing.T, stream string) float64 {
+	t.Helper()
+	metric := findMetric(t, "trackker_sse_subscribers", map[string]string{"stream": stream})
+	if metric == nil {
+		return 0
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestInstrumentExcludesEventStreams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /test/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /test/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", eventStreamContentType)
+		_, _ = io.WriteString(w, "event: ping\n\n")
+	})
+	handler := instrument(mux)
+
+	fastBefore, streamBefore := requestCount(t, "GET /test/fast"), requestCount(t, "GET /test/stream")
+	for _, path := range []string{"/test/fast", "/test/stream", "/test/fast"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := requestCount(t, "GET /test/fast") - fastBefore; got != 2 {
+		t.Errorf("fast route observed %d times, want 2", got)
+	}
+	if got := requestCount(t, "GET /test/stream") - streamBefore; got != 0 {
+		t.Errorf("event stream observed %d times in the latency histogram, want 0", got)
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Le gauge sse_subscribers et /health/live ne comptent que les navigateurs connectés,
+// pas les abonnements internes au tracker (sorties)
+func TestSSESubscribersCountsClientsOnly(t *testing.T) {
+	s := newTestServer(&config.Config{})
+	server := httptest.NewServer(instrument(s.routes()))
+	defer server.Close()
+
+	_, unsubscribe := s.tracker.SubscribeForTracks()
+	defer unsubscribe()
+
+	before := sseSubscribers(t, streamPublic)
+	ctx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	waitFor(t, "the SSE client to be counted", func() bool {
+		return sseSubscribers(t, streamPublic)-before == 1
+	})
+
+	health, err := server.Client().Get(server.URL + "/health/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live healthDTO
+	err = json.NewDecoder(health.Body).Decode(&live)
+	health.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Subscribers != 1 {
+		t.Errorf("health subscribers = %d, want 1", live.Subscribers)
+	}
+
+	disconnect()
+	waitFor(t, "the SSE client to be uncounted", func() bool {
+		return sseSubscribers(t, streamPublic) == before && s.sseClients.Load() == 0
+	})
+}
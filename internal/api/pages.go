@@ -0,0 +1,100 @@
+package api
+
+import (
+	"djtracker/internal/model"
+	"html/template"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+type historyPage struct {
+	Tracks []*model.Track
+	Limit  int
+}
+
+type eventPage struct {
+	Event  *model.Event
+	Tracks []*model.Track
+	// Live vrai pour l'événement en cours, complété au fil des tracks
+	Live bool
+}
+
+// parsePages charge les templates des pages publiques, la liste des tracks reprend
+// le template utilisé pour l'événement SSE history
+func parsePages() (*template.Template, error) {
+	return template.ParseFiles("templates/history-page.html", "templates/event-page.html", "templates/history.html")
+}
+
+// GetHistory Page publique des dernières tracks jouées (?limit=), mise à jour en direct
+func (s *Server) GetHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := parseLimit(r.URL.Query().Get("limit"), defaultHistoryLimit, maxHistoryLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		tracks, err := s.store.FindRecentTracks(limit)
+		if err != nil {
+			s.log.Error("Failed to load recent tracks", "err", err)
+			http.Error(w, "failed to load history", http.StatusInternalServerError)
+			return
+		}
+
+		s.renderPage(w, "history-page.html", &historyPage{Tracks: tracks, Limit: limit})
+	}
+}
+
+// GetEventPage Page publique de la setlist d'un événement, mise à jour en direct pour l'événement en cours
+func (s *Server) GetEventPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid event id", http.StatusBadRequest)
+			return
+		}
+
+		event, err := s.store.FindEvent(eventID)
+		if err != nil {
+			s.log.Error("Failed to find event", "event", eventID, "err", err)
+			http.Error(w, "failed to load event", http.StatusInternalServerError)
+			return
+		}
+		if event == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		all, err := s.store.FindEventTracks(eventID)
+		if err != nil {
+			s.log.Error("Failed to load event tracks", "event", eventID, "err", err)
+			http.Error(w, "failed to load event", http.StatusInternalServerError)
+			return
+		}
+		tracks := make([]*model.Track, 0, len(all))
+		for _, track := range all {
+			if !track.Skipped {
+				tracks = append(tracks, track)
+			}
+		}
+
+		current := s.store.CurrentEvent()
+		s.renderPage(w, "event-page.html", &eventPage{
+			Event:  event,
+			Tracks: tracks,
+			Live:   current != nil && current.ID == event.ID,
+		})
+	}
+}
+
+func (s *Server) renderPage(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.pages.ExecuteTemplate(w, name, data); err != nil {
+		s.log.Error("Failed to render page", "page", name, "err", err)
+	}
+}
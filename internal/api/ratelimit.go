@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiterSweep nombre de clients suivis au-delà duquel les entrées expirées sont purgées
+const rateLimiterSweep = 1024
+
+// rateLimiter autorise au plus limit actions par client sur une fenêtre glissante
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+// Allow enregistre une action du client si la limite n'est pas atteinte,
+// sinon retourne le délai avant la prochaine action possible
+func (l *rateLimiter) Allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.hits) > rateLimiterSweep {
+		for key, hits := range l.hits {
+			if len(l.recent(hits, now)) == 0 {
+				delete(l.hits, key)
+			}
+		}
+	}
+
+	hits := l.recent(l.hits[client], now)
+	if len(hits) >= l.limit {
+		l.hits[client] = hits
+		return false, hits[0].Add(l.window).Sub(now)
+	}
+	l.hits[client] = append(hits, now)
+	return true, 0
+}
+
+// recent retourne les actions encore dans la fenêtre, de la plus ancienne à la plus récente
+func (l *rateLimiter) recent(hits []time.Time, now time.Time) []time.Time {
+	for len(hits) > 0 && !hits[0].After(now.Add(-l.window)) {
+		hits = hits[1:]
+	}
+	return hits
+}
+
+// clientIP identifie le client par l'adresse IP de la connexion (RemoteAddr) : les en-têtes
+// X-Forwarded-For ne sont pas lus, derrière un reverse proxy tous les invités partagent donc
+// la même adresse et la même limite
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	start := time.Date(2024, 5, 18, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		client     string
+		at         time.Duration
+		ok         bool
+		retryAfter time.Duration
+	}{
+		{"first", "10.0.0.1", 0, true, 0},
+		{"second", "10.0.0.1", 20 * time.Second, true, 0},
+		// Limite atteinte : attente jusqu'à la sortie de la plus ancienne action de la fenêtre
+		{"limit reached", "10.0.0.1", 30 * time.Second, false, 30 * time.Second},
+		{"other client", "10.0.0.2", 30 * time.Second, true, 0},
+		// Un refus n'est pas compté comme une action
+		{"still limited", "10.0.0.1", 50 * time.Second, false, 10 * time.Second},
+		// Fenêtre glissante : la première action sort de la fenêtre à 1 minute pile
+		{"first expired", "10.0.0.1", time.Minute, true, 0},
+		{"limited by second", "10.0.0.1", time.Minute + 10*time.Second, false, 10 * time.Second},
+		{"window elapsed", "10.0.0.1", 3 * time.Minute, true, 0},
+	}
+
+	for _, tt := range tests {
+		ok, retryAfter := limiter.Allow(tt.client, start.Add(tt.at))
+		if ok != tt.ok || retryAfter != tt.retryAfter {
+			t.Errorf("%s: Allow(%s, +%s) = %t, %s, want %t, %s", tt.name, tt.client, tt.at, ok, retryAfter, tt.ok, tt.retryAfter)
+		}
+	}
+}
+
+// Au-delà de rateLimiterSweep clients suivis, les clients sans action récente sont oubliés
+func TestRateLimiterSweep(t *testing.T) {
+	limiter := newRateLimiter(1, time.Minute)
+	start := time.Date(2024, 5, 18, 22, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= rateLimiterSweep; i++ {
+		limiter.Allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256), start)
+	}
+	limiter.Allow("192.168.1.1", start.Add(30*time.Second))
+	if got := len(limiter.hits); got != rateLimiterSweep+2 {
+		t.Fatalf("tracked clients = %d, want %d before expiry", got, rateLimiterSweep+2)
+	}
+
+	// Fenêtre écoulée pour les premiers clients seulement
+	if ok, _ := limiter.Allow("192.168.1.2", start.Add(time.Minute)); !ok {
+		t.Fatal("new client refused")
+	}
+	if got := len(limiter.hits); got != 2 {
+		t.Errorf("tracked clients = %d, want 2 after the sweep", got)
+	}
+	if ok, retryAfter := limiter.Allow("192.168.1.1", start.Add(time.Minute)); ok || retryAfter != 30*time.Second {
+		t.Errorf("recent client after sweep = %t, %s, want still limited for 30s", ok, retryAfter)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{"192.0.2.1:51234", "192.0.2.1"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"192.0.2.1", "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		// Derrière un reverse proxy, l'en-tête n'est pas pris en compte
+		r.Header.Set("X-Forwarded-For", "203.0.113.7")
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("clientIP(%s) = %s, want %s", tt.remoteAddr, got, tt.want)
+		}
+	}
+}
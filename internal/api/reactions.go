@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// guestCookie identifie le navigateur d'un invité, pour ne compter qu'un like par track
+	guestCookie    = "trackker_guest"
+	guestCookieAge = 365 * 24 * time.Hour
+
+	likeLimit  = 30
+	likeWindow = time.Minute
+)
+
+type likeDTO struct {
+	TrackID int64 `json:"track_id"`
+	Likes   int   `json:"likes"`
+	// Liked faux si le navigateur avait déjà aimé la track
+	Liked bool `json:"liked"`
+}
+
+// LikeTrack Like du public sur la track en cours (?track_id= pour vérifier qu'elle n'a pas changé),
+// un seul like par navigateur et par track
+func (s *Server) LikeTrack() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := s.likeLimiter.Allow(clientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "too many likes", http.StatusTooManyRequests)
+			return
+		}
+
+		track := s.tracker.GetCurrentTrack()
+		if track == nil {
+			http.Error(w, "no track is playing", http.StatusNotFound)
+			return
+		}
+		if id := r.FormValue("track_id"); id != "" && id != strconv.FormatInt(track.ID, 10) {
+			http.Error(w, "track is no longer playing", http.StatusConflict)
+			return
+		}
+
+		token, err := guestToken(w, r)
+		if err != nil {
+			s.log.Error("Failed to generate guest token", "err", err)
+			http.Error(w, "failed to save like", http.StatusInternalServerError)
+			return
+		}
+
+		reactions, added, err := s.reactions.Like(track, token)
+		if err != nil {
+			s.log.Error("Failed to save like", "track", track.ID, "err", err)
+			http.Error(w, "failed to save like", http.StatusInternalServerError)
+			return
+		}
+
+		response := &likeDTO{TrackID: reactions.TrackID, Likes: reactions.Likes, Liked: added}
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write like response", "err", err)
+		}
+	}
+}
+
+// guestToken retourne le jeton du navigateur, créé et déposé en cookie à sa première réaction
+func guestToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(guestCookie); err == nil && len(cookie.Value) == 32 {
+		return cookie.Value, nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	http.SetCookie(w, &http.Cookie{
+		Name:     guestCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(guestCookieAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token, nil
+}
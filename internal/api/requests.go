@@ -0,0 +1,219 @@
+package api
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/service"
+	"djtracker/internal/service/library"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLibrarySearchLimit = 20
+	maxLibrarySearchLimit     = 50
+
+	defaultRequestLimit  = 3
+	defaultRequestWindow = 15 * time.Minute
+)
+
+// libraryEntryDTO morceau proposé au public, désigné par son identifiant : le chemin du fichier n'est pas exposé
+type libraryEntryDTO struct {
+	ID       string  `json:"id"`
+	Artist   string  `json:"artist,omitempty"`
+	Title    string  `json:"title"`
+	Album    string  `json:"album,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+}
+
+type songRequestDTO struct {
+	ID        int64   `json:"id"`
+	Path      string  `json:"path,omitempty"`
+	Artist    *string `json:"artist,omitempty"`
+	Title     string  `json:"title"`
+	Guest     *string `json:"guest,omitempty"`
+	Message   *string `json:"message,omitempty"`
+	Status    string  `json:"status"`
+	TrackID   *int64  `json:"track_id,omitempty"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt *string `json:"updated_at,omitempty"`
+}
+
+func newLibraryEntryDTO(entry *library.Entry) *libraryEntryDTO {
+	dto := &libraryEntryDTO{
+		ID:       entry.ID,
+		Artist:   entry.Artist,
+		Title:    entry.Title,
+		Album:    entry.Album,
+		Duration: entry.Duration.Seconds(),
+	}
+	if dto.Title == "" {
+		dto.Title = strings.TrimSuffix(filepath.Base(entry.Path), filepath.Ext(entry.Path))
+	}
+	return dto
+}
+
+func newSongRequestDTO(request *model.SongRequest) *songRequestDTO {
+	dto := &songRequestDTO{
+		ID:        request.ID,
+		Path:      request.Path,
+		Artist:    request.Artist,
+		Title:     request.Title,
+		Guest:     request.Guest,
+		Message:   request.Message,
+		Status:    request.Status,
+		TrackID:   request.TrackID,
+		CreatedAt: request.CreatedAt.Format(time.RFC3339),
+	}
+	if request.UpdatedAt != nil {
+		updatedAt := request.UpdatedAt.Format(time.RFC3339)
+		dto.UpdatedAt = &updatedAt
+	}
+	return dto
+}
+
+// newRequestLimiter limite les demandes par client selon requests.limit et requests.window
+func newRequestLimiter(limit, window int) *rateLimiter {
+	if limit <= 0 {
+		limit = defaultRequestLimit
+	}
+	duration := time.Duration(window) * time.Minute
+	if duration <= 0 {
+		duration = defaultRequestWindow
+	}
+	return newRateLimiter(limit, duration)
+}
+
+// requireRequests réserve le handler aux serveurs ayant activé les demandes du public (requests.enabled)
+func (s *Server) requireRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.Requests.Enabled {
+			http.Error(w, "song requests are disabled", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) LoadRequestsPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "static/requests.html")
+	}
+}
+
+// SearchLibrary Recherche des morceaux de la bibliothèque pouvant être demandés (?q=, ?limit=)
+func (s *Server) SearchLibrary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "missing q parameter", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query().Get("limit"), defaultLibrarySearchLimit, maxLibrarySearchLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries := s.requests.Search(query, limit)
+		response := make([]*libraryEntryDTO, 0, len(entries))
+		for _, entry := range entries {
+			response = append(response, newLibraryEntryDTO(entry))
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write library search response", "err", err)
+		}
+	}
+}
+
+// SubmitRequest Demande d'un morceau par le public (formulaire : id du morceau retourné par
+// SearchLibrary, guest, message), limitée par client (requests.limit sur requests.window minutes)
+func (s *Server) SubmitRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.FormValue("id")
+		if id == "" {
+			http.Error(w, "missing id parameter", http.StatusBadRequest)
+			return
+		}
+
+		if ok, retryAfter := s.requestLimiter.Allow(clientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "too many requests, please wait before asking again", http.StatusTooManyRequests)
+			return
+		}
+
+		request, err := s.requests.Submit(id, r.FormValue("guest"), r.FormValue("message"))
+		if errors.Is(err, service.ErrUnknownFile) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.log.Error("Failed to save song request", "id", id, "err", err)
+			http.Error(w, "failed to save request", http.StatusInternalServerError)
+			return
+		}
+
+		// Le public ne voit ni le chemin du fichier, ni les noms et messages des autres demandes
+		response := newSongRequestDTO(request)
+		response.Path, response.Guest, response.Message = "", nil, nil
+		if err := writeJSON(w, http.StatusCreated, response); err != nil {
+			s.log.Error("Failed to write request response", "err", err)
+		}
+	}
+}
+
+// GetRequests File des demandes de l'événement en cours, réservée au DJ
+func (s *Server) GetRequests() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests, err := s.requests.Queue()
+		if err != nil {
+			s.log.Error("Failed to load song requests", "err", err)
+			http.Error(w, "failed to load requests", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]*songRequestDTO, 0, len(requests))
+		for _, request := range requests {
+			response = append(response, newSongRequestDTO(request))
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write requests response", "err", err)
+		}
+	}
+}
+
+// DecideRequest Accepte ou refuse une demande encore ouverte, réservé au DJ
+func (s *Server) DecideRequest(accept bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid request id", http.StatusBadRequest)
+			return
+		}
+
+		request, err := s.requests.Decide(id, accept)
+		if errors.Is(err, service.ErrRequestClosed) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.log.Error("Failed to update song request", "request", id, "err", err)
+			http.Error(w, "failed to update request", http.StatusInternalServerError)
+			return
+		}
+		if request == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := writeJSON(w, http.StatusOK, newSongRequestDTO(request)); err != nil {
+			s.log.Error("Failed to write request response", "err", err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"djtracker/internal/service/library"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRequestsServer serveur acceptant les demandes, avec une bibliothèque contenant un fichier
+func newRequestsServer(t *testing.T) (*Server, repository.Store, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "Musique", "DJ privé")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "Aerodynamic.mp3")
+	if err := os.WriteFile(path, []byte("not really audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lib := library.New(discardLogger(), []string{dir})
+	if err := lib.Index(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.Config{}
+	conf.Requests.Enabled = true
+	store := repository.NewMemory()
+	if err := store.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	tracker := service.NewTracker(discardLogger(), conf, store, nil, nil)
+	requests := service.NewRequests(discardLogger(), store, lib)
+	return NewServer(conf, discardLogger(), tracker, store, &formatter.JsonFormatter{}, requests), store, path
+}
+
+func postRequest(handler http.Handler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "192.0.2.10:1234"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// Le public désigne les morceaux par leur identifiant, sans jamais voir leur chemin
+func TestPublicRequestsHidePaths(t *testing.T) {
+	s, store, path := newRequestsServer(t)
+	handler := s.routes()
+	dir := filepath.Dir(path)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/library/search?q=aero", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search = %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), dir) || strings.Contains(rec.Body.String(), `"path"`) {
+		t.Errorf("search response exposes the path: %s", rec.Body)
+	}
+	var entries []libraryEntryDTO
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != library.EntryID(path) || entries[0].Title != "Aerodynamic" {
+		t.Fatalf("search entries = %+v, want Aerodynamic with its id", entries)
+	}
+
+	rec = postRequest(handler, url.Values{"id": {entries[0].ID}, "guest": {"Léa"}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("submit = %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), dir) || strings.Contains(rec.Body.String(), `"path"`) {
+		t.Errorf("request response exposes the path: %s", rec.Body)
+	}
+
+	requests, err := store.FindSongRequests(store.CurrentEvent().ID)
+	if err != nil || len(requests) != 1 || requests[0].Path != path {
+		t.Fatalf("stored requests = %v, %v, want the file path resolved on the server", requests, err)
+	}
+}
+
+func TestSubmitRequestValidation(t *testing.T) {
+	s, _, path := newRequestsServer(t)
+	handler := s.routes()
+
+	tests := []struct {
+		name string
+		form url.Values
+		want int
+	}{
+		{"missing id", url.Values{}, http.StatusBadRequest},
+		// L'ancien paramètre n'est plus accepté : un chemin ne désigne aucun morceau
+		{"path instead of id", url.Values{"path": {path}}, http.StatusBadRequest},
+		{"path as id", url.Values{"id": {path}}, http.StatusNotFound},
+		{"unknown id", url.Values{"id": {library.EntryID("/elsewhere/Aerodynamic.mp3")}}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postRequest(handler, tt.form); rec.Code != tt.want {
+				t.Errorf("submit = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
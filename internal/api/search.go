@@ -0,0 +1,77 @@
+package api
+
+import (
+	"djtracker/internal/api/formatter"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type searchResultDTO struct {
+	Track      *formatter.TrackDTO `json:"track"`
+	EventID    int64               `json:"event_id"`
+	EventStart string              `json:"event_start"`
+	PlayAt     string              `json:"play_at"`
+	Score      float64             `json:"score"`
+}
+
+// SearchTracks Recherche dans tout l'historique des tracks jouées (?q=, ?limit=)
+func (s *Server) SearchTracks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			http.Error(w, "missing q parameter", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query().Get("limit"), defaultSearchLimit, maxSearchLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		results, err := s.store.SearchTracks(query, limit)
+		if err != nil {
+			s.log.Error("Failed to search tracks", "query", query, "err", err)
+			http.Error(w, "search failed", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]*searchResultDTO, 0, len(results))
+		for _, result := range results {
+			response = append(response, &searchResultDTO{
+				Track:      formatter.NewTrackDTO(result.Track),
+				EventID:    result.Track.EventID,
+				EventStart: result.EventStart.Format(time.RFC3339),
+				PlayAt:     result.Track.PlayAt.Format(time.RFC3339),
+				Score:      result.Score,
+			})
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write search response", "err", err)
+		}
+	}
+}
+
+// parseLimit lit un paramètre de pagination, borné à max
+func parseLimit(value string, fallback, max int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit: %s", value)
+	}
+	if limit > max {
+		return max, nil
+	}
+	return limit, nil
+}
@@ -1,51 +1,140 @@
 package api
 
 import (
+	"context"
 	"djtracker/internal/api/formatter"
 	"djtracker/internal/config"
+	"djtracker/internal/metrics"
+	"djtracker/internal/repository"
 	"djtracker/internal/service"
+	"djtracker/internal/service/analytics"
+	"errors"
 	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 type Server struct {
 	config    *config.Config
 	log       *slog.Logger
 	tracker   *service.Tracker
+	store     repository.Store
 	formatter formatter.Formatter
+	covers    *coverCache
+	analytics *analytics.Analytics
+	requests  *service.Requests
+	reactions *service.Reactions
+
+	requestLimiter *rateLimiter
+	likeLimiter    *rateLimiter
+
+	pages *template.Template
+
+	// sseClients nombre de navigateurs connectés aux flux SSE
+	sseClients atomic.Int64
 }
 
-func NewServer(config *config.Config, log *slog.Logger, service *service.Tracker, formatter formatter.Formatter) *Server {
+func NewServer(config *config.Config, log *slog.Logger, tracker *service.Tracker, store repository.Store, formatter formatter.Formatter, requests *service.Requests) *Server {
 	return &Server{
 		config:    config,
 		log:       log,
-		tracker:   service,
+		tracker:   tracker,
+		store:     store,
 		formatter: formatter,
+		covers:    newCoverCache(coverCacheSize),
+		analytics: analytics.New(store),
+		requests:  requests,
+		reactions: service.NewReactions(log, store),
+
+		requestLimiter: newRequestLimiter(config.Requests.Limit, config.Requests.Window),
+		likeLimiter:    newRateLimiter(likeLimit, likeWindow),
 	}
 }
 
-func (s *Server) Start() error {
+// routes Déclare les routes de l'application
+func (s *Server) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	mux.Handle("GET /health/live", s.Live())
+	mux.Handle("GET /health/ready", s.Ready())
+	mux.Handle("GET /metrics", metrics.Handler())
 
 	fs := http.FileServer(http.Dir("./static"))
 	mux.Handle("GET /static/", http.StripPrefix("/static/", fs))
 
 	mux.Handle("GET /", s.LoadIndex())
 	mux.Handle("GET /cover/", s.GetCover())
+	mux.Handle("GET /cover/{id}", s.GetCover())
 	mux.Handle("GET /events", s.ListenForTracksSSE())
+	mux.Handle("GET /events/{id}", s.GetEventPage())
+	mux.Handle("GET /history", s.GetHistory())
+	mux.Handle("GET /history/events", s.ListenForHistorySSE())
+
+	mux.Handle("GET /feed/tracks.atom", s.GetTracksAtom())
+	mux.Handle("GET /feed/events.rss", s.GetEventsRSS())
+	mux.Handle("GET /feed/tracks.json", s.GetTracksJSONFeed())
+
+	mux.Handle("POST /api/like", s.LikeTrack())
+
+	mux.Handle("GET /api/search", s.SearchTracks())
+	mux.Handle("GET /api/stats", s.GetStats())
+	mux.Handle("GET /api/events/{id}/stats", s.GetEventStats())
+	mux.Handle("GET /api/events/{id}/export", s.ExportEvent())
+
+	mux.Handle("GET /dj", s.requireDJ(s.LoadDJPage()))
+	mux.Handle("GET /dj/events", s.requireDJ(s.ListenForDJSSE()))
+	mux.Handle("GET /api/check", s.requireDJ(s.CheckTrack()))
+
+	mux.Handle("GET /requests", s.requireRequests(s.LoadRequestsPage()))
+	mux.Handle("GET /api/library/search", s.requireRequests(s.SearchLibrary()))
+	mux.Handle("POST /api/requests", s.requireRequests(s.SubmitRequest()))
+	mux.Handle("GET /api/requests", s.requireDJ(s.GetRequests()))
+	mux.Handle("POST /api/requests/{id}/accept", s.requireDJ(s.DecideRequest(true)))
+	mux.Handle("POST /api/requests/{id}/reject", s.requireDJ(s.DecideRequest(false)))
+	return mux
+}
+
+// Start Démarre le serveur HTTP et l'arrête proprement à l'annulation du contexte
+func (s *Server) Start(ctx context.Context) error {
+	pages, err := parsePages()
+	if err != nil {
+		return err
+	}
+	s.pages = pages
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", s.config.Server.BindAddress, s.config.Server.Port),
-		Handler: mux,
+		Handler: instrument(s.routes()),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.log.Info("Server listening on: " + server.Addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
 	}
 
-	s.log.Info("Server listening on: " + server.Addr)
-	if err := server.ListenAndServe(); err != nil {
+	s.log.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
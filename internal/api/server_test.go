@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service"
+	"djtracker/internal/sink"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closingSink sortie de test retenant sa fermeture
+type closingSink struct {
+	closed atomic.Bool
+}
+
+func (s *closingSink) Name() string                            { return "test" }
+func (s *closingSink) Send(context.Context, *sink.Event) error { return nil }
+func (s *closingSink) Close() error                            { s.closed.Store(true); return nil }
+
+// freePort réserve un port libre puis le relâche pour le serveur
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// returns vérifie que fn se termine avant le délai
+func returns(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+// Arrêt comme dans cmd/api : l'annulation du contexte arrête le serveur, ferme les flux SSE
+// ouverts avec l'événement close puis termine le tracker et les sorties
+func TestServerGracefulShutdown(t *testing.T) {
+	// Templates chargés depuis la racine du dépôt, comme au lancement du serveur
+	t.Chdir("../..")
+
+	conf := &config.Config{}
+	conf.Server.BindAddress = "127.0.0.1"
+	conf.Server.Port = freePort(t)
+	html, err := formatter.NewFormatter(conf, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := repository.NewMemory()
+	if err := store.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	p := &chanParser{tracks: make(chan *model.Track)}
+	tracker := service.NewTracker(discardLogger(), conf, store, p, service.NewEnricher(discardLogger(), nil))
+	s := NewServer(conf, discardLogger(), tracker, store, html, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker.StartTracking(ctx)
+	output := &closingSink{}
+	outputs := sink.NewDispatcher(discardLogger(), []sink.Sink{output})
+	outputs.Start(ctx, tracker)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start(ctx) }()
+
+	url := "http://127.0.0.1:" + conf.Server.Port
+	waitFor(t, "server to listen", func() bool {
+		resp, err := http.Get(url + "/health/live")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+
+	history := streamEvents(t, http.DefaultClient, url+"/history/events")
+	index := streamEvents(t, http.DefaultClient, url+"/events")
+	waitFor(t, "both SSE clients to connect", func() bool {
+		return s.sseClients.Load() == 2
+	})
+
+	cancel()
+
+	for name, events := range map[string]chan sseEvent{"history": history, "index": index} {
+		if event := nextEvent(t, events, "close", nil); event.data != "shutdown" {
+			t.Errorf("%s close event data = %q, want shutdown", name, event.data)
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("Start() = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not return after cancel")
+	}
+
+	returns(t, "tracker.Wait()", tracker.Wait)
+	returns(t, "outputs.Wait()", outputs.Wait)
+	if !output.closed.Load() {
+		t.Error("output not closed after shutdown")
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/service/analytics"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultStatsLimit = 10
+	maxStatsLimit     = 100
+)
+
+type artistCountDTO struct {
+	Artist string `json:"artist"`
+	Plays  int    `json:"plays"`
+}
+
+type transitionDTO struct {
+	FromID          int64   `json:"from_id"`
+	ToID            int64   `json:"to_id"`
+	IntervalSeconds float64 `json:"interval_seconds"`
+	GapSeconds      float64 `json:"gap_seconds"`
+}
+
+type eventStatsDTO struct {
+	EventID                 int64            `json:"event_id"`
+	EventStart              string           `json:"event_start"`
+	TrackCount              int              `json:"track_count"`
+	SkippedCount            int              `json:"skipped_count"`
+	SetLengthSeconds        float64          `json:"set_length_seconds"`
+	AverageTrackTimeSeconds float64          `json:"average_track_time_seconds"`
+	TopArtists              []artistCountDTO `json:"top_artists"`
+	Transitions             []transitionDTO  `json:"transitions"`
+	LongestGap              *transitionDTO   `json:"longest_gap,omitempty"`
+}
+
+type trackStatsDTO struct {
+	Track        *formatter.TrackDTO `json:"track"`
+	Plays        int                 `json:"plays"`
+	EventsPlayed int                 `json:"events_played"`
+	FirstPlay    string              `json:"first_play"`
+	LastPlay     string              `json:"last_play"`
+	Frequency    float64             `json:"frequency"`
+}
+
+type likedTrackDTO struct {
+	Track *formatter.TrackDTO `json:"track"`
+	Likes int                 `json:"likes"`
+}
+
+type statsDTO struct {
+	From       *string          `json:"from,omitempty"`
+	To         string           `json:"to"`
+	EventCount int              `json:"event_count"`
+	TrackCount int              `json:"track_count"`
+	TopTracks  []*trackStatsDTO `json:"top_tracks"`
+	TopArtists []artistCountDTO `json:"top_artists"`
+	MostLiked  []*likedTrackDTO `json:"most_liked"`
+}
+
+func newArtistCountDTOs(artists []analytics.ArtistCount) []artistCountDTO {
+	dtos := make([]artistCountDTO, 0, len(artists))
+	for _, a := range artists {
+		dtos = append(dtos, artistCountDTO{Artist: a.Artist, Plays: a.Plays})
+	}
+	return dtos
+}
+
+func newTransitionDTO(t *analytics.Transition) *transitionDTO {
+	return &transitionDTO{
+		FromID:          t.From.ID,
+		ToID:            t.To.ID,
+		IntervalSeconds: t.Interval.Seconds(),
+		GapSeconds:      t.Gap.Seconds(),
+	}
+}
+
+func newTrackStatsDTO(t *analytics.TrackStats) *trackStatsDTO {
+	return &trackStatsDTO{
+		Track:        formatter.NewTrackDTO(t.Track),
+		Plays:        t.Plays,
+		EventsPlayed: t.EventsPlayed,
+		FirstPlay:    t.FirstPlay.Format(time.RFC3339),
+		LastPlay:     t.LastPlay.Format(time.RFC3339),
+		Frequency:    t.Frequency,
+	}
+}
+
+// GetEventStats Statistiques d'un événement : durée du set, nombre de tracks, artistes les plus joués, enchaînements
+func (s *Server) GetEventStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid event id", http.StatusBadRequest)
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query().Get("limit"), defaultStatsLimit, maxStatsLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stats, err := s.analytics.EventStats(eventID, limit)
+		if err != nil {
+			s.log.Error("Failed to compute event stats", "event", eventID, "err", err)
+			http.Error(w, "failed to compute stats", http.StatusInternalServerError)
+			return
+		}
+		if stats == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		response := &eventStatsDTO{
+			EventID:                 stats.Event.ID,
+			EventStart:              stats.Event.Start.Format(time.RFC3339),
+			TrackCount:              stats.TrackCount,
+			SkippedCount:            stats.SkippedCount,
+			SetLengthSeconds:        stats.SetLength.Seconds(),
+			AverageTrackTimeSeconds: stats.AverageTrackTime.Seconds(),
+			TopArtists:              newArtistCountDTOs(stats.TopArtists),
+			Transitions:             make([]transitionDTO, 0, len(stats.Transitions)),
+		}
+		for i := range stats.Transitions {
+			response.Transitions = append(response.Transitions, *newTransitionDTO(&stats.Transitions[i]))
+		}
+		if stats.LongestGap != nil {
+			response.LongestGap = newTransitionDTO(stats.LongestGap)
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write stats response", "err", err)
+		}
+	}
+}
+
+// GetStats Statistiques sur une période (?from=, ?to=, ?limit=) : morceaux et artistes les plus joués, morceaux les plus aimés.
+// Avec ?path=, retourne uniquement les lectures du morceau correspondant.
+func (s *Server) GetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		from, err := parseDate(query.Get("from"), time.Time{}, false)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := parseDate(query.Get("to"), time.Now(), true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseLimit(query.Get("limit"), defaultStatsLimit, maxStatsLimit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if path := query.Get("path"); path != "" {
+			stats, err := s.analytics.TrackStats(path, from, to)
+			if err != nil {
+				s.log.Error("Failed to compute track stats", "path", path, "err", err)
+				http.Error(w, "failed to compute stats", http.StatusInternalServerError)
+				return
+			}
+			if stats == nil {
+				http.NotFound(w, r)
+				return
+			}
+			if err := writeJSON(w, http.StatusOK, newTrackStatsDTO(stats)); err != nil {
+				s.log.Error("Failed to write stats response", "err", err)
+			}
+			return
+		}
+
+		stats, err := s.analytics.Stats(from, to, limit)
+		if err != nil {
+			s.log.Error("Failed to compute stats", "err", err)
+			http.Error(w, "failed to compute stats", http.StatusInternalServerError)
+			return
+		}
+
+		response := &statsDTO{
+			To:         stats.To.Format(time.RFC3339),
+			EventCount: stats.EventCount,
+			TrackCount: stats.TrackCount,
+			TopTracks:  make([]*trackStatsDTO, 0, len(stats.TopTracks)),
+			TopArtists: newArtistCountDTOs(stats.TopArtists),
+			MostLiked:  make([]*likedTrackDTO, 0, len(stats.MostLiked)),
+		}
+		if !stats.From.IsZero() {
+			from := stats.From.Format(time.RFC3339)
+			response.From = &from
+		}
+		for _, track := range stats.TopTracks {
+			response.TopTracks = append(response.TopTracks, newTrackStatsDTO(track))
+		}
+		for _, liked := range stats.MostLiked {
+			response.MostLiked = append(response.MostLiked, &likedTrackDTO{
+				Track: formatter.NewTrackDTO(liked.Track),
+				Likes: liked.Likes,
+			})
+		}
+
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			s.log.Error("Failed to write stats response", "err", err)
+		}
+	}
+}
+
+// parseDate lit une date au format YYYY-MM-DD ou RFC 3339.
+// Avec endOfDay, une date sans heure désigne la fin de la journée.
+func parseDate(value string, fallback time.Time, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		if endOfDay {
+			return date.Add(24*time.Hour - time.Nanosecond), nil
+		}
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", value)
+	}
+	return date, nil
+}
@@ -1,10 +1,21 @@
 package api
 
 import (
+	"djtracker/internal/metrics"
+	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
+const eventStreamContentType = "text/event-stream"
+
+// Flux SSE, libellés du gauge sse_subscribers
+const (
+	streamPublic  = "public"
+	streamHistory = "history"
+	streamDJ      = "dj"
+)
+
 type SsePacket struct {
 	http.ResponseWriter
 	Event string
@@ -28,6 +39,17 @@ type Sse struct {
 	http.ResponseWriter
 }
 
+// countSSEClient compte un client connecté au flux jusqu'à l'appel de la fonction retournée.
+// Seuls les navigateurs sont comptés, pas les abonnements internes aux broadcasters (sorties...).
+func (s *Server) countSSEClient(stream string) func() {
+	s.sseClients.Add(1)
+	metrics.SSESubscribers.WithLabelValues(stream).Inc()
+	return func() {
+		s.sseClients.Add(-1)
+		metrics.SSESubscribers.WithLabelValues(stream).Dec()
+	}
+}
+
 func (w *Sse) SendEvent(event, data string) error {
 	packet := &SsePacket{
 		Event: event,
@@ -48,3 +70,10 @@ func (w *Sse) sendAndFlushPacket(p *SsePacket) error {
 func (w *Sse) Ping() (int, error) {
 	return w.ResponseWriter.Write([]byte(": ping\n\n"))
 }
+
+// writeJSON écrit la réponse JSON avec le code HTTP donné
+func writeJSON(w http.ResponseWriter, code int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	return json.NewEncoder(w).Encode(v)
+}
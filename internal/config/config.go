@@ -13,9 +13,15 @@ type Config struct {
 		BindAddress string `yaml:"bind_address"`
 		Port        string
 		Format      string
+		// PublicURL adresse publique du serveur utilisée dans les liens des flux, déduite de la requête si vide
+		PublicURL string `yaml:"public_url"`
+		// DJToken protège les pages et flux réservés au DJ, désactivés s'il est vide
+		DJToken string `yaml:"dj_token"`
 	}
 	Database struct {
-		Path string
+		Driver string
+		Path   string
+		DSN    string
 	}
 	Tracker struct {
 		History struct {
@@ -25,7 +31,100 @@ type Config struct {
 		Source struct {
 			Paths []string
 		}
+		// MinPlayTime durée minimale (en secondes) avant la track suivante pour qu'une track soit
+		// considérée comme jouée, les lectures plus courtes sont marquées passées (0 : désactivé)
+		MinPlayTime int `yaml:"min_play_time"`
 	}
+	// Requests demandes de morceaux du public (page /requests), désactivées si Enabled est faux
+	Requests struct {
+		Enabled bool
+		// Limit nombre de demandes acceptées par client (adresse IP) sur Window minutes, 3 par 15 minutes par défaut
+		Limit  int
+		Window int
+	}
+	// Outputs sorties vers lesquelles les changements de track sont transmis
+	Outputs struct {
+		Webhooks []Webhook
+		MQTT     MQTT
+		Files    Files
+		OSC      OSC
+		Icecast  Icecast
+		Scrobble Scrobble
+	}
+}
+
+// Scrobble envoi des lectures aux comptes ListenBrainz et Last.fm du DJ, chaque service désactivé sans identifiants
+type Scrobble struct {
+	ListenBrainz struct {
+		// Token jeton utilisateur (https://listenbrainz.org/settings/)
+		Token string
+		URL   string
+	} `yaml:"listenbrainz"`
+	LastFM struct {
+		APIKey string `yaml:"api_key"`
+		Secret string
+		// SessionKey clé de session de l'utilisateur obtenue par auth.getSession
+		SessionKey string `yaml:"session_key"`
+		URL        string
+	} `yaml:"lastfm"`
+}
+
+// Icecast mise à jour du titre d'un flux Icecast ou Shoutcast, désactivée si URL est vide
+type Icecast struct {
+	// URL adresse du serveur, par exemple http://localhost:8000
+	URL string
+	// Server icecast (par défaut) ou shoutcast
+	Server string
+	// Mount point de montage Icecast (/live), identifiant du flux (sid) Shoutcast v2
+	Mount    string
+	Username string
+	Password string
+	// Template titre envoyé (text/template), « Artiste - Titre » par défaut
+	Template string
+}
+
+// OSC envoi de la track en cours en OSC sur UDP (Resolume, TouchDesigner...), désactivé sans destinataire
+type OSC struct {
+	// Targets destinataires au format host:port
+	Targets []string
+}
+
+// Files fichiers réécrits à chaque track pour les logiciels de streaming (OBS...), désactivés si Directory est vide
+type Files struct {
+	Directory string
+	// Texts nom de fichier → template (text/template) de son contenu,
+	// nowplaying.txt, artist.txt et title.txt par défaut
+	Texts map[string]string
+	// Cover nom du fichier de pochette (cover.jpg par défaut)
+	Cover string
+}
+
+// MQTT publication de la track en cours sur un broker MQTT, désactivée si Broker est vide
+type MQTT struct {
+	// Broker adresse du broker, par exemple tcp://localhost:1883
+	Broker   string
+	ClientID string `yaml:"client_id"`
+	Username string
+	Password string
+	// Topic reçoit la track en cours en message retenu (trackker/now-playing par défaut)
+	Topic string
+	// ProgressTopic reçoit la progression de la track en cours chaque seconde (trackker/progress par défaut)
+	ProgressTopic string `yaml:"progress_topic"`
+	QoS           byte   `yaml:"qos"`
+}
+
+// Webhook requête HTTP envoyée à chaque événement du tracker
+type Webhook struct {
+	URL string
+	// Events types d'événements transmis (track, skipped), tous si vide
+	Events []string
+	// Secret clé de la signature HMAC-SHA256 du corps (en-tête X-Trackker-Signature)
+	Secret string
+	// Template corps de la requête (text/template), JSON de l'événement si vide
+	Template    string
+	ContentType string `yaml:"content_type"`
+	// MaxAttempts nombre maximal de tentatives d'envoi (5 par défaut)
+	MaxAttempts int `yaml:"max_attempts"`
 }
 
 func New() (*Config, error) {
@@ -39,6 +138,10 @@ func New() (*Config, error) {
 		return nil, err
 	}
 
+	if config.Database.Driver == "" {
+		config.Database.Driver = "sqlite"
+	}
+
 	return &config, nil
 }
 
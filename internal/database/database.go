@@ -3,11 +3,50 @@ package database
 import (
 	"database/sql"
 	"djtracker/internal/config"
+	"djtracker/internal/model"
 	"djtracker/internal/utils"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Moteurs de base de données pris en charge (database.driver)
+const (
+	SQLite   = "sqlite"
+	Postgres = "postgres"
 )
 
+// SQLiteTimeFormat format des dates enregistrées avec SQLite : en UTC et de largeur fixe, l'ordre du texte
+// est celui des dates, les comparaisons et les tris sont donc faits en SQL. Le pilote relit ce format.
+const SQLiteTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteTimePattern motif GLOB des dates déjà au format SQLiteTimeFormat
+const sqliteTimePattern = "????-??-??T??:??:??.?????????Z"
+
+// dateColumns colonnes DATETIME des tables, réécrites au format SQLiteTimeFormat
+var dateColumns = []struct {
+	table  string
+	column string
+}{
+	{"events", "start"},
+	{"tracks", "play_at"},
+	{"tracks", "skipped_at"},
+	{"parse_failures", "created_at"},
+	{"parse_failures", "resolved_at"},
+	{"webhook_deliveries", "created_at"},
+	{"scrobbles", "played_at"},
+	{"scrobbles", "created_at"},
+	{"scrobbles", "sent_at"},
+	{"song_requests", "created_at"},
+	{"song_requests", "updated_at"},
+	{"track_likes", "created_at"},
+}
+
 func createDbPath(dbPath string) error {
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return err
@@ -15,12 +54,23 @@ func createDbPath(dbPath string) error {
 	return nil
 }
 
-func UseDb(conf *config.Config, app func(db *sql.DB) error) error {
-	if err := createDbPath(conf.Database.Path); err != nil {
-		return err
+func open(conf *config.Config) (*sql.DB, error) {
+	switch conf.Database.Driver {
+	case SQLite:
+		if err := createDbPath(conf.Database.Path); err != nil {
+			return nil, err
+		}
+		// Plusieurs goroutines écrivent en base (tracker, sorties) : attente du verrou plutôt qu'une erreur SQLITE_BUSY
+		return sql.Open("sqlite", conf.Database.Path+"?_pragma=busy_timeout(5000)")
+	case Postgres:
+		return sql.Open("pgx", conf.Database.DSN)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", conf.Database.Driver)
 	}
+}
 
-	db, err := sql.Open("sqlite", conf.Database.Path)
+func UseDb(conf *config.Config, app func(db *sql.DB) error) error {
+	db, err := open(conf)
 	if err != nil {
 		return err
 	}
@@ -30,38 +80,103 @@ func UseDb(conf *config.Config, app func(db *sql.DB) error) error {
 		return err
 	}
 
-	if err := migrate(db); err != nil {
+	if err := migrate(&migrator{db: db, driver: conf.Database.Driver}); err != nil {
 		return err
 	}
 
 	return app(db)
 }
 
-func migrate(db *sql.DB) error {
-	if err := createEventsTable(db); err != nil {
+// migrator exécute les scripts de création écrits pour SQLite en les adaptant au moteur utilisé
+type migrator struct {
+	db     *sql.DB
+	driver string
+}
+
+// postgresTypes traduit les types SQLite des scripts de création vers leurs équivalents PostgreSQL
+var postgresTypes = map[string]string{
+	"INTEGER PRIMARY KEY AUTOINCREMENT": "BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY",
+	"DATETIME":                          "TIMESTAMPTZ",
+	"INTEGER":                           "BIGINT",
+	"REAL":                              "DOUBLE PRECISION",
+}
+
+// sqliteType repère les types de postgresTypes écrits comme mots entiers en dehors des chaînes entre apostrophes.
+// Les chaînes sont capturées pour être recopiées telles quelles ; la forme la plus longue est testée en premier.
+var sqliteType = regexp.MustCompile(`'(?:[^']|'')*'|\b(?:INTEGER PRIMARY KEY AUTOINCREMENT|DATETIME|INTEGER|REAL)\b`)
+
+func (m *migrator) translate(query string) string {
+	if m.driver != Postgres {
+		return query
+	}
+	return sqliteType.ReplaceAllStringFunc(query, func(match string) string {
+		if translated, ok := postgresTypes[match]; ok {
+			return translated
+		}
+		return match
+	})
+}
+
+func (m *migrator) exec(query string) error {
+	_, err := m.db.Exec(m.translate(query))
+	return err
+}
+
+func migrate(m *migrator) error {
+	if err := createEventsTable(m); err != nil {
+		return err
+	}
+
+	if err := createTracksTable(m); err != nil {
+		return err
+	}
+
+	if err := createTracksIdentityIndex(m); err != nil {
+		return err
+	}
+
+	if err := createParseFailuresTable(m); err != nil {
 		return err
 	}
 
-	if err := createTracksTable(db); err != nil {
+	if err := createTracksSearchIndex(m); err != nil {
+		return err
+	}
+
+	if err := createWebhookDeliveriesTable(m); err != nil {
+		return err
+	}
+
+	if err := createScrobblesTable(m); err != nil {
+		return err
+	}
+
+	if err := createSongRequestsTable(m); err != nil {
+		return err
+	}
+
+	if err := createTrackLikesTable(m); err != nil {
+		return err
+	}
+
+	if err := normalizeSQLiteDates(m); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func createEventsTable(db *sql.DB) error {
-	_, err := db.Exec(`
+func createEventsTable(m *migrator) error {
+	return m.exec(`
 		CREATE TABLE IF NOT EXISTS events (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			start DATETIME NOT NULL 
 		)
 	`)
-
-	return err
 }
 
-func createTracksTable(db *sql.DB) error {
-	_, err := db.Exec(`
+func createTracksTable(m *migrator) error {
+	err := m.exec(`
 		CREATE TABLE IF NOT EXISTS tracks (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			event_id INTEGER NOT NULL,
@@ -70,9 +185,381 @@ func createTracksTable(db *sql.DB) error {
 			play_at DATETIME NOT NULL,
 			duration INTEGER,
 			path TEXT,
+			remix VARCHAR(255),
+			album VARCHAR(255),
+			genre VARCHAR(255),
+			music_key VARCHAR(16),
+			bpm REAL,
+			year INTEGER,
+			deck INTEGER,
+			file_size INTEGER,
+			metadata_sources TEXT,
+			repeat_of INTEGER,
+			skipped BOOLEAN NOT NULL DEFAULT FALSE,
+			skipped_at DATETIME,
+			search_text TEXT,
+			identity TEXT,
 			
 			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE 
 		)
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Colonnes ajoutées après la création initiale de la table
+	return m.addMissingColumns("tracks", []column{
+		{"remix", "VARCHAR(255)"},
+		{"album", "VARCHAR(255)"},
+		{"genre", "VARCHAR(255)"},
+		{"music_key", "VARCHAR(16)"},
+		{"bpm", "REAL"},
+		{"year", "INTEGER"},
+		{"deck", "INTEGER"},
+		{"file_size", "INTEGER"},
+		{"metadata_sources", "TEXT"},
+		{"repeat_of", "INTEGER"},
+		{"skipped", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"search_text", "TEXT"},
+		{"skipped_at", "DATETIME"},
+		{"identity", "TEXT"},
+	})
+}
+
+type column struct {
+	name       string
+	definition string
+}
+
+// addMissingColumns ajoute à une table existante les colonnes qui n'y sont pas encore
+func (m *migrator) addMissingColumns(table string, columns []column) error {
+	if m.driver == Postgres {
+		for _, c := range columns {
+			if err := m.exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`, table, c.name, c.definition)); err != nil {
+				return fmt.Errorf("error adding column %s.%s: %w", table, c.name, err)
+			}
+		}
+		return nil
+	}
+
+	rows, err := m.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		if existing[c.name] {
+			continue
+		}
+		if err := m.exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, c.name, c.definition)); err != nil {
+			return fmt.Errorf("error adding column %s.%s: %w", table, c.name, err)
+		}
+	}
+	return nil
+}
+
+// createTracksIdentityIndex remplit la colonne identity (voir model.Track.Identity) des tracks enregistrées
+// avant son ajout et l'indexe : les lectures précédentes d'un morceau sont cherchées à chaque nouvelle track
+func createTracksIdentityIndex(m *migrator) error {
+	rows, err := m.db.Query(`SELECT id, artist, name FROM tracks WHERE identity IS NULL`)
+	if err != nil {
+		return err
+	}
+	identities := make(map[int64]string)
+	for rows.Next() {
+		var track model.Track
+		var artist sql.NullString
+		if err := rows.Scan(&track.ID, &artist, &track.Name); err != nil {
+			rows.Close()
+			return err
+		}
+		if artist.Valid {
+			track.Artist = &artist.String
+		}
+		identities[track.ID] = track.Identity()
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(identities) > 0 {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		for id, identity := range identities {
+			if _, err := tx.Exec(`UPDATE tracks SET identity = $1 WHERE id = $2`, identity, id); err != nil {
+				return fmt.Errorf("error filling track identity: %w", err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return m.exec(`CREATE INDEX IF NOT EXISTS tracks_event_identity_idx ON tracks (event_id, identity)`)
+}
+
+func createParseFailuresTable(m *migrator) error {
+	return m.exec(`
+		CREATE TABLE IF NOT EXISTS parse_failures (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			source VARCHAR(32) NOT NULL,
+			file TEXT,
+			kind VARCHAR(32) NOT NULL,
+			raw TEXT NOT NULL,
+			error TEXT,
+			created_at DATETIME NOT NULL,
+			resolved_at DATETIME,
+
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+}
+
+// fillTracksSearchText remplit la colonne search_text des tracks enregistrées avant son ajout
+func fillTracksSearchText(m *migrator) error {
+	rows, err := m.db.Query(`SELECT id, artist, name, path, remix, album FROM tracks WHERE search_text IS NULL`)
+	if err != nil {
+		return err
+	}
+	texts := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var artist, name, path, remix, album sql.NullString
+		if err := rows.Scan(&id, &artist, &name, &path, &remix, &album); err != nil {
+			rows.Close()
+			return err
+		}
+		texts[id] = utils.SearchText(artist.String, name.String, path.String, remix.String, album.String)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for id, text := range texts {
+		if _, err := tx.Exec(`UPDATE tracks SET search_text = $1 WHERE id = $2`, text, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// normalizeSQLiteDates réécrit au format SQLiteTimeFormat les dates enregistrées avec SQLite
+// par les versions précédentes (time.Time.String, avec le décalage horaire local).
+// Les colonnes TIMESTAMPTZ de PostgreSQL sont comparées correctement et restent inchangées.
+func normalizeSQLiteDates(m *migrator) error {
+	if m.driver == Postgres {
+		return nil
+	}
+	for _, c := range dateColumns {
+		if err := normalizeDates(m, c.table, c.column); err != nil {
+			return fmt.Errorf("error normalizing dates of %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func normalizeDates(m *migrator, table, column string) error {
+	rows, err := m.db.Query(fmt.Sprintf(`SELECT id, %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s NOT GLOB ?`, column, table), sqliteTimePattern)
+	if err != nil {
+		return err
+	}
+	dates := make(map[int64]time.Time)
+	for rows.Next() {
+		var id int64
+		var date time.Time
+		if err := rows.Scan(&id, &date); err != nil {
+			rows.Close()
+			return err
+		}
+		dates[id] = date
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	update := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE id = ?`, table, column)
+	for id, date := range dates {
+		if _, err := tx.Exec(update, date.UTC().Format(SQLiteTimeFormat), id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func createWebhookDeliveriesTable(m *migrator) error {
+	return m.exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook TEXT NOT NULL,
+			event_type VARCHAR(32) NOT NULL,
+			track_id INTEGER,
+			attempt INTEGER NOT NULL,
+			status_code INTEGER,
+			error TEXT,
+			success BOOLEAN NOT NULL,
+			created_at DATETIME NOT NULL
+		)
+	`)
+}
+
+func createScrobblesTable(m *migrator) error {
+	return m.exec(`
+		CREATE TABLE IF NOT EXISTS scrobbles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service VARCHAR(32) NOT NULL,
+			track_id INTEGER NOT NULL,
+			artist VARCHAR(255) NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			album VARCHAR(255),
+			duration INTEGER,
+			played_at DATETIME NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			attempts INTEGER NOT NULL,
+			error TEXT,
+			created_at DATETIME NOT NULL,
+			sent_at DATETIME
+		)
+	`)
+}
+
+func createSongRequestsTable(m *migrator) error {
+	return m.exec(`
+		CREATE TABLE IF NOT EXISTS song_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			path TEXT NOT NULL,
+			artist VARCHAR(255),
+			title VARCHAR(255) NOT NULL,
+			guest VARCHAR(64),
+			message VARCHAR(255),
+			status VARCHAR(16) NOT NULL,
+			track_id INTEGER,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME,
+
+			FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+		)
+	`)
+}
+
+// createTrackLikesTable un like par navigateur (token) et par track
+func createTrackLikesTable(m *migrator) error {
+	return m.exec(`
+		CREATE TABLE IF NOT EXISTS track_likes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			track_id INTEGER NOT NULL,
+			token VARCHAR(64) NOT NULL,
+			created_at DATETIME NOT NULL,
+
+			UNIQUE (track_id, token),
+			FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE
+		)
+	`)
+}
+
+// createTracksSearchIndex crée l'index plein texte de l'historique : une table FTS5 synchronisée
+// par triggers avec SQLite, un index GIN sur la colonne search_text avec PostgreSQL.
+// PostgreSQL ne retire pas les accents sans l'extension unaccent, la colonne est donc remplie sans accents
+// par l'application (voir utils.SearchText).
+func createTracksSearchIndex(m *migrator) error {
+	if m.driver == Postgres {
+		if err := fillTracksSearchText(m); err != nil {
+			return fmt.Errorf("error filling search text: %w", err)
+		}
+		statements := []string{
+			// Index sur les colonnes d'origine, qui ne retirait pas les accents
+			`DROP INDEX IF EXISTS tracks_search_idx`,
+			`CREATE INDEX IF NOT EXISTS tracks_search_text_idx ON tracks USING GIN (to_tsvector('simple', coalesce(search_text, '')))`,
+		}
+		for _, statement := range statements {
+			if err := m.exec(statement); err != nil {
+				return fmt.Errorf("error creating search index: %w", err)
+			}
+		}
+		return nil
+	}
+
+	var exists int
+	err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'tracks_fts'`).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	statements := []string{`
+		CREATE VIRTUAL TABLE tracks_fts USING fts5(
+			artist, name, path, remix, album,
+			content = 'tracks',
+			content_rowid = 'id',
+			tokenize = 'unicode61 remove_diacritics 2'
+		)
+	`, `
+		CREATE TRIGGER IF NOT EXISTS tracks_fts_insert AFTER INSERT ON tracks BEGIN
+			INSERT INTO tracks_fts (rowid, artist, name, path, remix, album)
+			VALUES (new.id, new.artist, new.name, new.path, new.remix, new.album);
+		END
+	`, `
+		CREATE TRIGGER IF NOT EXISTS tracks_fts_delete AFTER DELETE ON tracks BEGIN
+			INSERT INTO tracks_fts (tracks_fts, rowid, artist, name, path, remix, album)
+			VALUES ('delete', old.id, old.artist, old.name, old.path, old.remix, old.album);
+		END
+	`, `
+		CREATE TRIGGER IF NOT EXISTS tracks_fts_update AFTER UPDATE ON tracks BEGIN
+			INSERT INTO tracks_fts (tracks_fts, rowid, artist, name, path, remix, album)
+			VALUES ('delete', old.id, old.artist, old.name, old.path, old.remix, old.album);
+			INSERT INTO tracks_fts (rowid, artist, name, path, remix, album)
+			VALUES (new.id, new.artist, new.name, new.path, new.remix, new.album);
+		END
+	`,
+		// Indexation des tracks enregistrées avant la création de l'index
+		`INSERT INTO tracks_fts (tracks_fts) VALUES ('rebuild')`,
+	}
+
+	for _, statement := range statements {
+		if err := m.exec(statement); err != nil {
+			return fmt.Errorf("error creating search index: %w", err)
+		}
+	}
+	return nil
 }
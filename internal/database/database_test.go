@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"djtracker/internal/config"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"primary key", `id INTEGER PRIMARY KEY AUTOINCREMENT,`, `id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,`},
+		{"types", `start DATETIME NOT NULL, bpm REAL, year INTEGER`, `start TIMESTAMPTZ NOT NULL, bpm DOUBLE PRECISION, year BIGINT`},
+		{"identifiers", `REALM_ID INTEGERS, DATETIME_COL TEXT`, `REALM_ID INTEGERS, DATETIME_COL TEXT`},
+		{"string literal", `DEFAULT 'INTEGER REAL', n INTEGER`, `DEFAULT 'INTEGER REAL', n BIGINT`},
+		{"escaped quote", `DEFAULT 'it''s REAL', n REAL`, `DEFAULT 'it''s REAL', n DOUBLE PRECISION`},
+	}
+
+	postgres := &migrator{driver: Postgres}
+	sqlite := &migrator{driver: SQLite}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgres.translate(tt.query); got != tt.want {
+				t.Errorf("translate(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if got := sqlite.translate(tt.query); got != tt.query {
+				t.Errorf("translate(%q) with SQLite = %q, want unchanged", tt.query, got)
+			}
+		})
+	}
+}
+
+// Les dates écrites par les versions précédentes (time.Time.String, décalage local)
+// sont réécrites en UTC au format SQLiteTimeFormat, sans changer l'instant enregistré
+func TestNormalizeSQLiteDates(t *testing.T) {
+	conf := &config.Config{}
+	conf.Database.Driver = SQLite
+	conf.Database.Path = filepath.Join(t.TempDir(), "test.db")
+
+	paris := time.FixedZone("CEST", 2*3600)
+	start := time.Date(2024, 6, 21, 23, 30, 0, 123456789, paris)
+	resolved := time.Date(2024, 6, 22, 1, 0, 0, 0, paris)
+
+	err := UseDb(conf, func(db *sql.DB) error {
+		if _, err := db.Exec(`INSERT INTO events (id, start) VALUES (1, ?)`, start.String()); err != nil {
+			return err
+		}
+		_, err := db.Exec(`
+			INSERT INTO parse_failures (event_id, source, kind, raw, created_at, resolved_at)
+			VALUES (1, 'virtualdj', 'invalid', 'raw', ?, ?), (1, 'virtualdj', 'invalid', 'raw', ?, NULL)
+		`, start.String(), resolved.String(), "2024-06-21T21:30:00.000000000Z")
+		if err != nil {
+			return err
+		}
+
+		m := &migrator{db: db, driver: SQLite}
+		if err := migrate(m); err != nil {
+			return err
+		}
+		// Déjà normalisées : rien à réécrire
+		if err := migrate(m); err != nil {
+			return err
+		}
+
+		tests := []struct {
+			query string
+			want  string
+			at    time.Time
+		}{
+			{`SELECT start, CAST(start AS TEXT) FROM events WHERE id = 1`, "2024-06-21T21:30:00.123456789Z", start},
+			{`SELECT created_at, CAST(created_at AS TEXT) FROM parse_failures WHERE id = 1`, "2024-06-21T21:30:00.123456789Z", start},
+			{`SELECT resolved_at, CAST(resolved_at AS TEXT) FROM parse_failures WHERE id = 1`, "2024-06-21T23:00:00.000000000Z", resolved},
+			{`SELECT created_at, CAST(created_at AS TEXT) FROM parse_failures WHERE id = 2`, "2024-06-21T21:30:00.000000000Z", start.Truncate(time.Second)},
+		}
+		for _, tt := range tests {
+			var at time.Time
+			var raw string
+			if err := db.QueryRow(tt.query).Scan(&at, &raw); err != nil {
+				return err
+			}
+			if raw != tt.want || !at.Equal(tt.at) {
+				t.Errorf("%s = %q (%s), want %q (%s)", tt.query, raw, at, tt.want, tt.at)
+			}
+		}
+
+		var unresolved int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM parse_failures WHERE resolved_at IS NULL`).Scan(&unresolved); err != nil {
+			return err
+		}
+		if unresolved != 1 {
+			t.Errorf("%d unresolved failures, want 1", unresolved)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"djtracker/internal/model"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "trackker"
+
+// Registry regroupe toutes les métriques exposées par /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	TracksParsed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tracks_parsed_total",
+		Help:      "Number of tracks read from the history source.",
+	})
+
+	TracksPersisted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tracks_persisted_total",
+		Help:      "Number of tracks saved into the history database.",
+	})
+
+	TracksFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tracks_failed_total",
+		Help:      "Number of tracks that could not be saved into the history database.",
+	})
+
+	ParserRestarts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parser_restarts_total",
+		Help:      "Number of times the history reader crashed and was restarted.",
+	})
+
+	ParseErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_errors_total",
+		Help:      "Number of history entries that could not be parsed, by kind.",
+	}, []string{"kind"})
+
+	CoverCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cover_cache_hits_total",
+		Help:      "Number of cover requests served from the cache.",
+	})
+
+	CoverCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cover_cache_misses_total",
+		Help:      "Number of cover requests that required reading the track file.",
+	})
+
+	SinkEventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "output_events_dropped_total",
+		Help:      "Number of events dropped because an output could not keep up, by output.",
+	}, []string{"sink"})
+
+	SinkErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "output_errors_total",
+		Help:      "Number of events an output failed to deliver, by output.",
+	}, []string{"sink"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, server-sent event streams excluded.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	SSESubscribers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sse_subscribers",
+		Help:      "Number of connected SSE clients, by stream.",
+	}, []string{"stream"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		TracksParsed,
+		TracksPersisted,
+		TracksFailed,
+		ParserRestarts,
+		ParseErrors,
+		CoverCacheHits,
+		CoverCacheMisses,
+		SinkEventsDropped,
+		SinkErrors,
+		HTTPRequestDuration,
+		SSESubscribers,
+	)
+
+	// Initialise les séries à 0 pour qu'elles soient visibles avant la première erreur
+	for _, kind := range []string{model.FailureRead, model.FailureMissingPrefix, model.FailureMissingPath, model.FailureInvalidData} {
+		ParseErrors.WithLabelValues(kind)
+	}
+}
+
+// Handler retourne le handler HTTP au format d'exposition texte de Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// WebhookDelivery trace une tentative d'envoi d'un événement à un webhook
+type WebhookDelivery struct {
+	ID         int64     `db:"id"`
+	Webhook    string    `db:"webhook"`
+	EventType  string    `db:"event_type"`
+	TrackID    *int64    `db:"track_id"`
+	Attempt    int       `db:"attempt"`
+	StatusCode *int      `db:"status_code"`
+	Error      *string   `db:"error"`
+	Success    bool      `db:"success"`
+	CreatedAt  time.Time `db:"created_at"`
+}
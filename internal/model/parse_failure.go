@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Types d'échec de lecture d'une entrée d'historique
+const (
+	FailureRead          = "read"
+	FailureMissingPrefix = "missing_prefix"
+	FailureMissingPath   = "missing_path"
+	FailureInvalidData   = "invalid_data"
+)
+
+// ParseFailure conserve une entrée d'historique illisible pour pouvoir la retraiter plus tard
+type ParseFailure struct {
+	ID         int64      `db:"id"`
+	EventID    int64      `db:"event_id"`
+	Source     string     `db:"source"`
+	File       string     `db:"file"`
+	Kind       string     `db:"kind"`
+	Raw        string     `db:"raw"`
+	Error      string     `db:"error"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ResolvedAt *time.Time `db:"resolved_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// Reactions réactions du public sur une track
+type Reactions struct {
+	TrackID int64
+	Likes   int
+}
+
+// LikedTrack track et nombre de likes reçus pendant sa lecture
+type LikedTrack struct {
+	Track *Track
+	Likes int
+}
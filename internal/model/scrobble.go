@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// États d'un scrobble dans la file d'envoi
+const (
+	ScrobbleQueued = "queued"
+	ScrobbleSent   = "sent"
+	ScrobbleFailed = "failed"
+)
+
+// Scrobble lecture à transmettre à un service de scrobbling (ListenBrainz, Last.fm),
+// conservée en file tant qu'elle n'a pas pu être envoyée
+type Scrobble struct {
+	ID        int64         `db:"id"`
+	Service   string        `db:"service"`
+	TrackID   int64         `db:"track_id"`
+	Artist    string        `db:"artist"`
+	Title     string        `db:"title"`
+	Album     *string       `db:"album"`
+	Duration  time.Duration `db:"duration"`
+	PlayedAt  time.Time     `db:"played_at"`
+	Status    string        `db:"status"`
+	Attempts  int           `db:"attempts"`
+	Error     *string       `db:"error"`
+	CreatedAt time.Time     `db:"created_at"`
+	SentAt    *time.Time    `db:"sent_at"`
+}
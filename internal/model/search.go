@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// SearchResult est une track jouée correspondant à une recherche dans l'historique
+type SearchResult struct {
+	Track      *Track
+	EventStart time.Time
+	// Score de pertinence, plus il est élevé plus le résultat est pertinent
+	Score float64
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// États d'une demande de morceau
+const (
+	RequestPending  = "pending"
+	RequestAccepted = "accepted"
+	RequestRejected = "rejected"
+	RequestPlayed   = "played"
+)
+
+// SongRequest demande d'un morceau de la bibliothèque faite par le public pendant un événement
+type SongRequest struct {
+	ID      int64   `db:"id"`
+	EventID int64   `db:"event_id"`
+	Path    string  `db:"path"`
+	Artist  *string `db:"artist"`
+	Title   string  `db:"title"`
+	Guest   *string `db:"guest"`
+	Message *string `db:"message"`
+	Status  string  `db:"status"`
+	// TrackID track de l'historique correspondant à la lecture du morceau demandé
+	TrackID   *int64     `db:"track_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// IsOpen indique si la demande attend encore d'être jouée
+func (r *SongRequest) IsOpen() bool {
+	return r.Status == RequestPending || r.Status == RequestAccepted
+}
@@ -1,20 +1,80 @@
 package model
 
 import (
+	"djtracker/internal/utils"
+	"strings"
 	"time"
 )
 
+// Origines possibles des métadonnées d'une track
+const (
+	SourceHistory  = "history"
+	SourceFile     = "file"
+	SourceLibrary  = "library"
+	SourceFilename = "filename"
+)
+
+// MetadataSources associe chaque champ renseigné (artist, title, album, duration, bpm) à l'origine de sa valeur
+type MetadataSources map[string]string
+
 type Track struct {
-	ID       int64         `db:"id"`
-	EventID  int64         `db:"event_id"`
-	Artist   *string       `db:"artist"`
-	Name     string        `db:"name"`
-	PlayAt   time.Time     `db:"play_at"`
-	Path     string        `db:"path"`
-	Duration time.Duration `db:"duration"`
+	ID       int64           `db:"id"`
+	EventID  int64           `db:"event_id"`
+	Artist   *string         `db:"artist"`
+	Name     string          `db:"name"`
+	PlayAt   time.Time       `db:"play_at"`
+	Path     string          `db:"path"`
+	Duration time.Duration   `db:"duration"`
+	Remix    *string         `db:"remix"`
+	Album    *string         `db:"album"`
+	Genre    *string         `db:"genre"`
+	Key      *string         `db:"music_key"`
+	BPM      *float64        `db:"bpm"`
+	Year     *int            `db:"year"`
+	Deck     *int            `db:"deck"`
+	FileSize *int64          `db:"file_size"`
+	Sources  MetadataSources `db:"metadata_sources"`
+	// RepeatOf identifiant de la lecture précédente du même morceau pendant l'événement
+	RepeatOf *int64 `db:"repeat_of"`
+	// Skipped indique une track chargée puis remplacée avant la durée minimale de lecture
+	Skipped bool `db:"skipped"`
 }
 
+// unknownDuration est la durée supposée d'une track dont la durée n'a pas pu être déterminée
+// (format non pris en charge, fichier absent...)
+const unknownDuration = 15 * time.Minute
+
+// IsFinished indique si la track est terminée.
+// Une track dont la durée est inconnue n'est pas terminée dès son chargement :
+// elle reste en cours pendant unknownDuration, ou jusqu'à la track suivante.
 func (t *Track) IsFinished(now time.Time) bool {
-	end := t.PlayAt.Add(t.Duration)
+	duration := t.Duration
+	if duration <= 0 {
+		duration = unknownDuration
+	}
+	end := t.PlayAt.Add(duration)
 	return end.Before(now)
 }
+
+// SearchText retourne le texte indexé pour la recherche dans l'historique (voir utils.SearchText) :
+// artiste, titre, chemin, remix et album
+func (t *Track) SearchText() string {
+	values := []string{t.Name, t.Path}
+	for _, value := range []*string{t.Artist, t.Remix, t.Album} {
+		if value != nil {
+			values = append(values, *value)
+		}
+	}
+	return utils.SearchText(values...)
+}
+
+// Identity retourne une clé identifiant le morceau quel que soit le fichier :
+// artiste et titre sans accents, majuscules ni ponctuation
+func (t *Track) Identity() string {
+	artist := ""
+	if t.Artist != nil {
+		artist = *t.Artist
+	}
+	return strings.Join(utils.Words(utils.FoldDiacritics(artist)), " ") + " - " +
+		strings.Join(utils.Words(utils.FoldDiacritics(t.Name)), " ")
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackIsFinished(t *testing.T) {
+	start := time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		duration time.Duration
+		elapsed  time.Duration
+		want     bool
+	}{
+		{"playing", 4 * time.Minute, 3 * time.Minute, false},
+		{"finished", 4 * time.Minute, 5 * time.Minute, true},
+		// Durée inconnue : la track reste en cours pendant unknownDuration
+		{"unknown duration just loaded", 0, time.Second, false},
+		{"unknown duration playing", 0, unknownDuration - time.Second, false},
+		{"unknown duration expired", 0, unknownDuration + time.Second, true},
+		{"negative duration", -time.Minute, time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := &Track{PlayAt: start, Duration: tt.duration}
+			if got := track.IsFinished(start.Add(tt.elapsed)); got != tt.want {
+				t.Errorf("IsFinished(+%s) = %v, want %v", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package model
+
+// Critères de détection d'une track déjà jouée
+const (
+	MatchedByPath     = "path"
+	MatchedByIdentity = "identity"
+)
+
+// RepeatWarning signale au DJ une track déjà jouée pendant l'événement
+type RepeatWarning struct {
+	Track     *Track
+	Previous  *Track
+	MatchedBy string
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/model"
+	"fmt"
+)
+
+// AddWebhookDelivery enregistre une tentative d'envoi à un webhook
+func (r *Repository) AddWebhookDelivery(delivery *model.WebhookDelivery) error {
+	id, err := r.insert(`
+		INSERT INTO webhook_deliveries (webhook, event_type, track_id, attempt, status_code, error, success, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, delivery.Webhook, delivery.EventType, delivery.TrackID, delivery.Attempt, delivery.StatusCode,
+		delivery.Error, delivery.Success, delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting webhook delivery: %w", err)
+	}
+	delivery.ID = id
+	return nil
+}
+
+// FindWebhookDeliveries retourne les dernières tentatives d'envoi, de la plus récente à la plus ancienne
+func (r *Repository) FindWebhookDeliveries(limit int) ([]*model.WebhookDelivery, error) {
+	rows, err := r.query(`
+		SELECT id, webhook, event_type, track_id, attempt, status_code, error, success, created_at
+		FROM webhook_deliveries
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		var trackID sql.Null[int64]
+		var statusCode sql.Null[int]
+		var deliveryError sql.Null[string]
+		err := rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.EventType, &trackID, &delivery.Attempt,
+			&statusCode, &deliveryError, &delivery.Success, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.TrackID = nullToPtr(trackID)
+		delivery.StatusCode = nullToPtr(statusCode)
+		delivery.Error = nullToPtr(deliveryError)
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/model"
+	"errors"
+	"time"
+)
+
+// FindEvent retourne l'événement correspondant à l'identifiant, nil s'il n'existe pas
+func (r *Repository) FindEvent(id int64) (*model.Event, error) {
+	var event model.Event
+	err := r.queryRow(`
+		SELECT id, start FROM events WHERE id = ?
+	`, id).Scan(&event.ID, &event.Start)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// FindEvents retourne les événements commencés entre from et to, du plus ancien au plus récent
+func (r *Repository) FindEvents(from, to time.Time) ([]*model.Event, error) {
+	rows, err := r.query(`
+		SELECT id, start FROM events WHERE start >= ? AND start <= ? ORDER BY start
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var event model.Event
+		if err := rows.Scan(&event.ID, &event.Start); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// FindRecentEvents retourne les derniers événements, du plus récent au plus ancien
+func (r *Repository) FindRecentEvents(limit int) ([]*model.Event, error) {
+	rows, err := r.query(`
+		SELECT id, start FROM events ORDER BY start DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var event model.Event
+		if err := rows.Scan(&event.ID, &event.Start); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// FindTrack retourne la track correspondant à l'identifiant, nil si elle n'existe pas
+func (r *Repository) FindTrack(id int64) (*model.Track, error) {
+	track, err := scanTrack(r.queryRow(`
+		SELECT `+trackColumns+` FROM tracks WHERE id = ?
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+// FindRecentTracks retourne les dernières tracks jouées, hors tracks passées, de la plus récente à la plus ancienne
+func (r *Repository) FindRecentTracks(limit int) ([]*model.Track, error) {
+	return r.findTracks(`
+		SELECT `+trackColumns+` FROM tracks WHERE skipped = ? ORDER BY play_at DESC, id DESC LIMIT ?
+	`, false, limit)
+}
+
+// FindLastTrackChange retourne la date de la lecture la plus récente ou de la dernière track passée
+func (r *Repository) FindLastTrackChange() (time.Time, error) {
+	var last time.Time
+	for _, query := range []string{
+		`SELECT play_at FROM tracks ORDER BY play_at DESC LIMIT 1`,
+		`SELECT skipped_at FROM tracks WHERE skipped_at IS NOT NULL ORDER BY skipped_at DESC LIMIT 1`,
+	} {
+		var at time.Time
+		err := r.queryRow(query).Scan(&at)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	return last, nil
+}
+
+// FindEventTracks retourne les tracks d'un événement dans l'ordre de lecture
+func (r *Repository) FindEventTracks(eventID int64) ([]*model.Track, error) {
+	return r.findTracks(`
+		SELECT `+trackColumns+` FROM tracks WHERE event_id = ? ORDER BY play_at, id
+	`, eventID)
+}
+
+// FindEventPlays retourne les lectures de l'événement correspondant au fichier ou au morceau (voir TrackStore)
+func (r *Repository) FindEventPlays(eventID int64, path, identity string) ([]*model.Track, error) {
+	if path == "" && identity == "" {
+		return nil, nil
+	}
+	return r.findTracks(`
+		SELECT `+trackColumns+` FROM tracks
+		WHERE event_id = ? AND skipped = ? AND ((? AND LOWER(path) = LOWER(?)) OR (? AND identity = ?))
+		ORDER BY play_at DESC, id DESC
+	`, eventID, false, path != "", path, identity != "", identity)
+}
+
+// FindTracksBetween retourne les tracks jouées entre from et to, dans l'ordre de lecture
+func (r *Repository) FindTracksBetween(from, to time.Time) ([]*model.Track, error) {
+	return r.findTracks(`
+		SELECT `+trackColumns+` FROM tracks WHERE play_at >= ? AND play_at <= ? ORDER BY play_at, id
+	`, from, to)
+}
+
+func (r *Repository) findTracks(query string, args ...any) ([]*model.Track, error) {
+	rows, err := r.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracks []*model.Track
+	for rows.Next() {
+		track, err := scanTrack(rows)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, rows.Err()
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"djtracker/internal/model"
+	"fmt"
+	"time"
+)
+
+// AddLike enregistre le like d'un navigateur sur une track, ignoré s'il l'a déjà aimée
+func (r *Repository) AddLike(trackID int64, token string, at time.Time) (bool, error) {
+	result, err := r.exec(`
+		INSERT INTO track_likes (track_id, token, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (track_id, token) DO NOTHING
+	`, trackID, token, at)
+	if err != nil {
+		return false, fmt.Errorf("error inserting like: %w", err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return added > 0, nil
+}
+
+// CountLikes retourne le nombre de likes d'une track
+func (r *Repository) CountLikes(trackID int64) (int, error) {
+	var likes int
+	err := r.queryRow(`SELECT COUNT(*) FROM track_likes WHERE track_id = ?`, trackID).Scan(&likes)
+	return likes, err
+}
+
+// FindMostLikedTracks retourne les tracks jouées entre from et to ayant reçu au moins un like,
+// de la plus aimée à la moins aimée
+func (r *Repository) FindMostLikedTracks(from, to time.Time, limit int) ([]*model.LikedTrack, error) {
+	rows, err := r.query(`
+		SELECT `+trackColumnsOf("t")+`, l.likes
+		FROM tracks t
+		JOIN (SELECT track_id, COUNT(*) AS likes FROM track_likes GROUP BY track_id) l ON l.track_id = t.id
+		WHERE t.play_at >= ? AND t.play_at <= ?
+		ORDER BY l.likes DESC, t.play_at DESC
+		LIMIT ?
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var liked []*model.LikedTrack
+	for rows.Next() {
+		result := &model.LikedTrack{}
+		track, err := scanTrack(rowWithExtra{rows, []any{&result.Likes}})
+		if err != nil {
+			return nil, err
+		}
+		result.Track = track
+		liked = append(liked, result)
+	}
+	return liked, rows.Err()
+}
@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"djtracker/internal/model"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory garde les données en mémoire, sans persistance.
+// Prévu pour les tests unitaires du tracker.
+type Memory struct {
+	mu         sync.RWMutex
+	events     []*model.Event
+	tracks     []*model.Track
+	failures   []*model.ParseFailure
+	deliveries []*model.WebhookDelivery
+	scrobbles  []*model.Scrobble
+	requests   []*model.SongRequest
+	likes      []memoryLike
+	// lastSkip date de la dernière track marquée comme passée
+	lastSkip time.Time
+}
+
+type memoryLike struct {
+	trackID int64
+	token   string
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
+
+func (m *Memory) PrepareEvent() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.events) == 0 {
+		m.events = append(m.events, &model.Event{ID: 1, Start: time.Now()})
+	}
+	return nil
+}
+
+// AddEvent crée un événement commençant à start, qui devient l'événement en cours.
+// Permet aux tests de simuler plusieurs soirées.
+func (m *Memory) AddEvent(start time.Time) *model.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	event := &model.Event{ID: int64(len(m.events) + 1), Start: start}
+	m.events = append(m.events, event)
+	copied := *event
+	return &copied
+}
+
+func (m *Memory) CurrentEvent() *model.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.events) == 0 {
+		return nil
+	}
+	return m.events[len(m.events)-1]
+}
+
+func (m *Memory) AddTrackToHistory(track *model.Track) error {
+	event := m.CurrentEvent()
+	if event == nil {
+		return ErrNoEvent
+	}
+	return m.AddTrackToEvent(event.ID, track)
+}
+
+func (m *Memory) AddTrackToEvent(eventID int64, track *model.Track) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	track.ID = int64(len(m.tracks) + 1)
+	track.EventID = eventID
+	stored := *track
+	m.tracks = append(m.tracks, &stored)
+	return nil
+}
+
+func (m *Memory) MarkTrackSkipped(id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id >= 1 && id <= int64(len(m.tracks)) {
+		m.tracks[id-1].Skipped = true
+		if at.After(m.lastSkip) {
+			m.lastSkip = at
+		}
+	}
+	return nil
+}
+
+func (m *Memory) FindLastTrack() (*model.Track, error) {
+	event := m.CurrentEvent()
+	if event == nil {
+		return nil, ErrNoEvent
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i := len(m.tracks) - 1; i >= 0; i-- {
+		if m.tracks[i].EventID == event.ID {
+			track := *m.tracks[i]
+			return &track, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) FindEvent(id int64) (*model.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, e := range m.events {
+		if e.ID == id {
+			event := *e
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) FindEvents(from, to time.Time) ([]*model.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var events []*model.Event
+	for _, e := range m.events {
+		if !e.Start.Before(from) && !e.Start.After(to) {
+			event := *e
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+func (m *Memory) FindRecentEvents(limit int) ([]*model.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]*model.Event, 0, len(m.events))
+	for _, e := range m.events {
+		event := *e
+		events = append(events, &event)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.After(events[j].Start)
+	})
+	return truncate(events, limit), nil
+}
+
+func (m *Memory) FindTrack(id int64) (*model.Track, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id < 1 || id > int64(len(m.tracks)) {
+		return nil, nil
+	}
+	track := *m.tracks[id-1]
+	return &track, nil
+}
+
+func (m *Memory) FindRecentTracks(limit int) ([]*model.Track, error) {
+	tracks := m.findTracks(func(t *model.Track) bool {
+		return !t.Skipped
+	})
+	slices.Reverse(tracks)
+	return truncate(tracks, limit), nil
+}
+
+func (m *Memory) FindEventTracks(eventID int64) ([]*model.Track, error) {
+	return m.findTracks(func(t *model.Track) bool {
+		return t.EventID == eventID
+	}), nil
+}
+
+func (m *Memory) FindEventPlays(eventID int64, path, identity string) ([]*model.Track, error) {
+	tracks := m.findTracks(func(t *model.Track) bool {
+		if t.EventID != eventID || t.Skipped {
+			return false
+		}
+		return path != "" && strings.EqualFold(t.Path, path) || identity != "" && t.Identity() == identity
+	})
+	slices.Reverse(tracks)
+	return tracks, nil
+}
+
+func (m *Memory) FindTracksBetween(from, to time.Time) ([]*model.Track, error) {
+	return m.findTracks(func(t *model.Track) bool {
+		return !t.PlayAt.Before(from) && !t.PlayAt.After(to)
+	}), nil
+}
+
+func (m *Memory) FindLastTrackChange() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	last := m.lastSkip
+	for _, t := range m.tracks {
+		if t.PlayAt.After(last) {
+			last = t.PlayAt
+		}
+	}
+	return last, nil
+}
+
+func (m *Memory) findTracks(match func(t *model.Track) bool) []*model.Track {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var tracks []*model.Track
+	for _, t := range m.tracks {
+		if match(t) {
+			track := *t
+			tracks = append(tracks, &track)
+		}
+	}
+	sort.SliceStable(tracks, func(i, j int) bool {
+		return tracks[i].PlayAt.Before(tracks[j].PlayAt)
+	})
+	return tracks
+}
+
+func (m *Memory) AddParseFailure(failure *model.ParseFailure) error {
+	event := m.CurrentEvent()
+	if event == nil {
+		return ErrNoEvent
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failure.ID = int64(len(m.failures) + 1)
+	failure.EventID = event.ID
+	stored := *failure
+	m.failures = append(m.failures, &stored)
+	return nil
+}
+
+func (m *Memory) FindParseFailures(includeResolved bool) ([]*model.ParseFailure, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var failures []*model.ParseFailure
+	for _, f := range m.failures {
+		if includeResolved || f.ResolvedAt == nil {
+			failure := *f
+			failures = append(failures, &failure)
+		}
+	}
+	return failures, nil
+}
+
+func (m *Memory) FindParseFailure(id int64) (*model.ParseFailure, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id < 1 || id > int64(len(m.failures)) {
+		return nil, nil
+	}
+	failure := *m.failures[id-1]
+	return &failure, nil
+}
+
+func (m *Memory) ResolveParseFailure(id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id >= 1 && id <= int64(len(m.failures)) {
+		m.failures[id-1].ResolvedAt = &at
+	}
+	return nil
+}
+
+func (m *Memory) SearchTracks(query string, limit int) ([]*model.SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []*model.SearchResult
+	for _, t := range m.tracks {
+		words := strings.Fields(t.SearchText())
+
+		score := 0.0
+		for _, term := range terms {
+			matched := false
+			for _, word := range words {
+				if strings.HasPrefix(word, term) {
+					matched = true
+					score++
+				}
+			}
+			if !matched {
+				score = 0
+				break
+			}
+		}
+		if score == 0 {
+			continue
+		}
+
+		track := *t
+		result := &model.SearchResult{Track: &track, Score: score}
+		if t.EventID >= 1 && t.EventID <= int64(len(m.events)) {
+			result.EventStart = m.events[t.EventID-1].Start
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Track.PlayAt.After(results[j].Track.PlayAt)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *Memory) AddWebhookDelivery(delivery *model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery.ID = int64(len(m.deliveries) + 1)
+	stored := *delivery
+	m.deliveries = append(m.deliveries, &stored)
+	return nil
+}
+
+func (m *Memory) FindWebhookDeliveries(limit int) ([]*model.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	deliveries := make([]*model.WebhookDelivery, 0, len(m.deliveries))
+	for i := len(m.deliveries) - 1; i >= 0; i-- {
+		delivery := *m.deliveries[i]
+		deliveries = append(deliveries, &delivery)
+	}
+	return truncate(deliveries, limit), nil
+}
+
+func (m *Memory) AddScrobble(scrobble *model.Scrobble) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scrobble.ID = int64(len(m.scrobbles) + 1)
+	stored := *scrobble
+	m.scrobbles = append(m.scrobbles, &stored)
+	return nil
+}
+
+func (m *Memory) FindQueuedScrobbles(service string, limit int) ([]*model.Scrobble, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var scrobbles []*model.Scrobble
+	for _, s := range m.scrobbles {
+		if s.Service == service && s.Status == model.ScrobbleQueued {
+			scrobble := *s
+			scrobbles = append(scrobbles, &scrobble)
+		}
+	}
+	sort.SliceStable(scrobbles, func(i, j int) bool {
+		return scrobbles[i].PlayedAt.Before(scrobbles[j].PlayedAt)
+	})
+	return truncate(scrobbles, limit), nil
+}
+
+func (m *Memory) UpdateScrobble(scrobble *model.Scrobble) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if scrobble.ID >= 1 && scrobble.ID <= int64(len(m.scrobbles)) {
+		stored := *scrobble
+		m.scrobbles[scrobble.ID-1] = &stored
+	}
+	return nil
+}
+
+func (m *Memory) AddSongRequest(request *model.SongRequest) error {
+	event := m.CurrentEvent()
+	if event == nil {
+		return ErrNoEvent
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	request.ID = int64(len(m.requests) + 1)
+	request.EventID = event.ID
+	stored := *request
+	m.requests = append(m.requests, &stored)
+	return nil
+}
+
+func (m *Memory) FindSongRequest(id int64) (*model.SongRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if id < 1 || id > int64(len(m.requests)) {
+		return nil, nil
+	}
+	request := *m.requests[id-1]
+	return &request, nil
+}
+
+func (m *Memory) FindSongRequests(eventID int64) ([]*model.SongRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var requests []*model.SongRequest
+	for _, r := range m.requests {
+		if r.EventID == eventID {
+			request := *r
+			requests = append(requests, &request)
+		}
+	}
+	return requests, nil
+}
+
+func (m *Memory) UpdateSongRequest(request *model.SongRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if request.ID >= 1 && request.ID <= int64(len(m.requests)) {
+		stored := *request
+		m.requests[request.ID-1] = &stored
+	}
+	return nil
+}
+
+func (m *Memory) AddLike(trackID int64, token string, _ time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	like := memoryLike{trackID: trackID, token: token}
+	if slices.Contains(m.likes, like) {
+		return false, nil
+	}
+	m.likes = append(m.likes, like)
+	return true, nil
+}
+
+func (m *Memory) CountLikes(trackID int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	likes := 0
+	for _, like := range m.likes {
+		if like.trackID == trackID {
+			likes++
+		}
+	}
+	return likes, nil
+}
+
+func (m *Memory) FindMostLikedTracks(from, to time.Time, limit int) ([]*model.LikedTrack, error) {
+	tracks, _ := m.FindTracksBetween(from, to)
+
+	var liked []*model.LikedTrack
+	for _, track := range tracks {
+		if likes, _ := m.CountLikes(track.ID); likes > 0 {
+			liked = append(liked, &model.LikedTrack{Track: track, Likes: likes})
+		}
+	}
+	sort.SliceStable(liked, func(i, j int) bool {
+		if liked[i].Likes != liked[j].Likes {
+			return liked[i].Likes > liked[j].Likes
+		}
+		return liked[i].Track.PlayAt.After(liked[j].Track.PlayAt)
+	})
+	return truncate(liked, limit), nil
+}
+
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
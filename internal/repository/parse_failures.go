@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/model"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AddParseFailure met en quarantaine une entrée d'historique illisible pour l'événement courant
+func (r *Repository) AddParseFailure(failure *model.ParseFailure) error {
+	if r.event == nil {
+		return ErrNoEvent
+	}
+	id, err := r.insert(`
+		INSERT INTO parse_failures (event_id, source, file, kind, raw, error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, r.event.ID, failure.Source, failure.File, failure.Kind, failure.Raw, failure.Error, failure.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting parse failure: %w", err)
+	}
+	failure.ID = id
+	failure.EventID = r.event.ID
+	return nil
+}
+
+// FindParseFailures retourne les entrées en quarantaine, de la plus ancienne à la plus récente
+func (r *Repository) FindParseFailures(includeResolved bool) ([]*model.ParseFailure, error) {
+	rows, err := r.query(`
+		SELECT id, event_id, source, file, kind, raw, error, created_at, resolved_at
+		FROM parse_failures
+		WHERE ? OR resolved_at IS NULL
+		ORDER BY id
+	`, includeResolved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []*model.ParseFailure
+	for rows.Next() {
+		failure, err := scanParseFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, failure)
+	}
+	return failures, rows.Err()
+}
+
+// FindParseFailure retourne l'entrée en quarantaine correspondant à l'identifiant, nil si elle n'existe pas
+func (r *Repository) FindParseFailure(id int64) (*model.ParseFailure, error) {
+	row := r.queryRow(`
+		SELECT id, event_id, source, file, kind, raw, error, created_at, resolved_at
+		FROM parse_failures
+		WHERE id = ?
+	`, id)
+
+	failure, err := scanParseFailure(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return failure, err
+}
+
+// ResolveParseFailure marque l'entrée comme retraitée
+func (r *Repository) ResolveParseFailure(id int64, at time.Time) error {
+	_, err := r.exec(`
+		UPDATE parse_failures SET resolved_at = ? WHERE id = ?
+	`, at, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanParseFailure(row scanner) (*model.ParseFailure, error) {
+	var failure model.ParseFailure
+	var file, errorMessage sql.NullString
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(
+		&failure.ID,
+		&failure.EventID,
+		&failure.Source,
+		&file,
+		&failure.Kind,
+		&failure.Raw,
+		&errorMessage,
+		&failure.CreatedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	failure.File = file.String
+	failure.Error = errorMessage.String
+	if resolvedAt.Valid {
+		failure.ResolvedAt = &resolvedAt.Time
+	}
+	return &failure, nil
+}
@@ -1,32 +1,168 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"djtracker/internal/database"
 	"djtracker/internal/model"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
-
-	_ "modernc.org/sqlite"
 )
 
+// Repository stocke les données dans une base SQL (SQLite ou PostgreSQL).
+// Les requêtes sont écrites avec des paramètres '?' et adaptées au moteur par rebind.
 type Repository struct {
-	log   *slog.Logger
-	db    *sql.DB
-	event *model.Event
+	log    *slog.Logger
+	db     *sql.DB
+	driver string
+	event  *model.Event
 }
 
-func New(log *slog.Logger, db *sql.DB) *Repository {
+func New(log *slog.Logger, db *sql.DB, driver string) *Repository {
 	return &Repository{
-		log: log,
-		db:  db,
+		log:    log,
+		db:     db,
+		driver: driver,
+	}
+}
+
+// rebind remplace les paramètres '?' par '$1', '$2'... pour PostgreSQL.
+// Les '?' écrits dans une chaîne entre apostrophes ou un identifiant entre guillemets sont conservés.
+func (r *Repository) rebind(query string) string {
+	if r.driver != database.Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			// Un guillemet doublé ('') ferme puis rouvre la chaîne : le résultat est le même
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// bindArgs adapte les paramètres au moteur : avec SQLite, les dates sont écrites au format
+// database.SQLiteTimeFormat pour être comparées et triées en SQL
+func (r *Repository) bindArgs(args []any) []any {
+	if r.driver == database.Postgres {
+		return args
+	}
+
+	bound := make([]any, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			bound[i] = value.UTC().Format(database.SQLiteTimeFormat)
+		case *time.Time:
+			if value != nil {
+				bound[i] = value.UTC().Format(database.SQLiteTimeFormat)
+			}
+		default:
+			bound[i] = arg
+		}
+	}
+	return bound
+}
+
+func (r *Repository) exec(query string, args ...any) (sql.Result, error) {
+	return r.db.Exec(r.rebind(query), r.bindArgs(args)...)
+}
+
+func (r *Repository) query(query string, args ...any) (*rows, error) {
+	result, err := r.db.Query(r.rebind(query), r.bindArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{result}, nil
+}
+
+func (r *Repository) queryRow(query string, args ...any) *row {
+	return &row{r.db.QueryRow(r.rebind(query), r.bindArgs(args)...)}
+}
+
+// rows résultat d'une requête, dont les dates sont lues dans le fuseau local (voir localTimes)
+type rows struct {
+	*sql.Rows
+}
+
+func (r *rows) Scan(dest ...any) error {
+	if err := r.Rows.Scan(dest...); err != nil {
+		return err
+	}
+	localTimes(dest)
+	return nil
+}
+
+// row résultat d'une requête sur une ligne, dont les dates sont lues dans le fuseau local (voir localTimes)
+type row struct {
+	*sql.Row
+}
+
+func (r *row) Scan(dest ...any) error {
+	if err := r.Row.Scan(dest...); err != nil {
+		return err
+	}
+	localTimes(dest)
+	return nil
+}
+
+// localTimes ramène les dates lues dans le fuseau local, quel que soit leur format en base (UTC avec SQLite)
+func localTimes(dest []any) {
+	for _, d := range dest {
+		switch value := d.(type) {
+		case *time.Time:
+			*value = value.Local()
+		case *sql.NullTime:
+			if value.Valid {
+				value.Time = value.Time.Local()
+			}
+		}
+	}
+}
+
+// insert exécute un INSERT et retourne l'identifiant généré
+func (r *Repository) insert(query string, args ...any) (int64, error) {
+	var id int64
+	err := r.queryRow(query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+// CurrentEvent retourne l'événement chargé par PrepareEvent
+func (r *Repository) CurrentEvent() *model.Event {
+	return r.event
+}
+
+// Ping vérifie que la base répond à une requête de lecture
+func (r *Repository) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return err
 	}
+	var one int
+	return r.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
 }
 
 func (r *Repository) PrepareEvent() error {
 	var last model.Event
-	err := r.db.QueryRow(`
+	err := r.queryRow(`
 		SELECT id, start
 		FROM events
 		ORDER BY start DESC
@@ -59,14 +195,13 @@ func (r *Repository) PrepareEvent() error {
 }
 
 func (r *Repository) createNewEvent(date time.Time) error {
-	res, err := r.db.Exec(`
+	id, err := r.insert(`
 		INSERT INTO events (start) VALUES (?)
 	`, date)
 	if err != nil {
 		return fmt.Errorf("error creating new event: %w", err)
 	}
 
-	id, _ := res.LastInsertId()
 	r.event = &model.Event{
 		ID:    id,
 		Start: date,
@@ -75,35 +210,81 @@ func (r *Repository) createNewEvent(date time.Time) error {
 	return nil
 }
 
-func (r *Repository) AddTrackToHistory(track *model.Track) {
-	res, err := r.db.Exec(`
-		INSERT INTO tracks (event_id, artist, name, play_at, duration, path) VALUES (?, ?, ?, ?, ?, ?)
-	`, r.event.ID, track.Artist, track.Name, track.PlayAt, track.Duration, track.Path)
+func (r *Repository) AddTrackToHistory(track *model.Track) error {
+	if r.event == nil {
+		return ErrNoEvent
+	}
+	return r.AddTrackToEvent(r.event.ID, track)
+}
+
+// AddTrackToEvent enregistre la track dans l'historique d'un événement donné
+func (r *Repository) AddTrackToEvent(eventID int64, track *model.Track) error {
+	id, err := r.insert(`
+		INSERT INTO tracks (event_id, artist, name, play_at, duration, path, remix, album, genre, music_key, bpm, year, deck, file_size, metadata_sources, repeat_of, skipped, search_text, identity)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, eventID, track.Artist, track.Name, track.PlayAt, track.Duration, track.Path,
+		track.Remix, track.Album, track.Genre, track.Key, track.BPM, track.Year, track.Deck, track.FileSize,
+		encodeSources(track.Sources), track.RepeatOf, track.Skipped, track.SearchText(), track.Identity())
 
 	if err != nil {
-		r.log.Warn("Failed to insert track into history", "event", r.event.ID, "track", fmt.Sprintf("%#v", track))
-		return
+		r.log.Warn("Failed to insert track into history", "event", eventID, "track", fmt.Sprintf("%#v", track))
+		return fmt.Errorf("error inserting track: %w", err)
 	}
-	r.log.Info("Track successfully saved", "event", r.event.ID, "track", fmt.Sprintf("%#v", track))
+	track.ID = id
+	track.EventID = eventID
+	r.log.Info("Track successfully saved", "event", eventID, "track", fmt.Sprintf("%#v", track))
+	return nil
+}
 
-	id, err := res.LastInsertId()
+// MarkTrackSkipped marque une track enregistrée comme passée (voir model.Track.Skipped)
+func (r *Repository) MarkTrackSkipped(id int64, at time.Time) error {
+	_, err := r.exec(`UPDATE tracks SET skipped = ?, skipped_at = ? WHERE id = ?`, true, at, id)
 	if err != nil {
-		r.log.Warn("Failed to retrieve generated ID for track", "event", r.event.ID, "track", fmt.Sprintf("%#v", track))
-		return
+		return fmt.Errorf("error marking track %d as skipped: %w", id, err)
 	}
-	track.ID = id
+	return nil
 }
 
 func (r *Repository) FindLastTrack() (*model.Track, error) {
-	rows := r.db.QueryRow(`
-		SELECT id, event_id ,artist, name, play_at, duration, path FROM tracks WHERE event_id = ? ORDER BY id DESC LIMIT 1
+	if r.event == nil {
+		return nil, ErrNoEvent
+	}
+	row := r.queryRow(`
+		SELECT `+trackColumns+` FROM tracks WHERE event_id = ? ORDER BY id DESC LIMIT 1
 	`, r.event.ID)
 
+	track, err := scanTrack(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return track, nil
+}
+
+// trackColumns liste les colonnes lues par scanTrack, dans l'ordre
+const trackColumns = `id, event_id, artist, name, play_at, duration, path, remix, album, genre, music_key, bpm, year, deck, file_size, metadata_sources, repeat_of, skipped`
+
+// trackColumnsOf préfixe les colonnes lues par scanTrack avec l'alias de la table, pour les jointures
+func trackColumnsOf(alias string) string {
+	columns := strings.Split(trackColumns, ", ")
+	for i, column := range columns {
+		columns[i] = alias + "." + column
+	}
+	return strings.Join(columns, ", ")
+}
+
+func scanTrack(row scanner) (*model.Track, error) {
 	var track model.Track
 
-	var artist sql.NullString
+	var artist, remix, album, genre, key, sources sql.Null[string]
+	var bpm sql.Null[float64]
+	var year, deck sql.Null[int]
+	var fileSize, repeatOf sql.Null[int64]
 
-	err := rows.Scan(
+	err := row.Scan(
 		&track.ID,
 		&track.EventID,
 		&artist,
@@ -111,18 +292,64 @@ func (r *Repository) FindLastTrack() (*model.Track, error) {
 		&track.PlayAt,
 		&track.Duration,
 		&track.Path,
+		&remix,
+		&album,
+		&genre,
+		&key,
+		&bpm,
+		&year,
+		&deck,
+		&fileSize,
+		&sources,
+		&repeatOf,
+		&track.Skipped,
 	)
-
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	if artist.Valid {
-		track.Artist = &artist.String
-	}
+	track.Artist = nullToPtr(artist)
+	track.Remix = nullToPtr(remix)
+	track.Album = nullToPtr(album)
+	track.Genre = nullToPtr(genre)
+	track.Key = nullToPtr(key)
+	track.BPM = nullToPtr(bpm)
+	track.Year = nullToPtr(year)
+	track.Deck = nullToPtr(deck)
+	track.FileSize = nullToPtr(fileSize)
+	track.Sources = decodeSources(sources)
+	track.RepeatOf = nullToPtr(repeatOf)
 
 	return &track, nil
 }
+
+// encodeSources stocke les origines des métadonnées en JSON, NULL si elles sont inconnues
+func encodeSources(sources model.MetadataSources) *string {
+	if len(sources) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(sources)
+	if err != nil {
+		return nil
+	}
+	encoded := string(data)
+	return &encoded
+}
+
+func decodeSources(data sql.Null[string]) model.MetadataSources {
+	if !data.Valid || data.V == "" {
+		return nil
+	}
+	var sources model.MetadataSources
+	if err := json.Unmarshal([]byte(data.V), &sources); err != nil {
+		return nil
+	}
+	return sources
+}
+
+func nullToPtr[T any](n sql.Null[T]) *T {
+	if !n.Valid {
+		return nil
+	}
+	return &n.V
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// postgresDSNEnv désigne une base PostgreSQL existante pour les tests.
+// Sans cette variable, une instance embarquée est démarrée (binaire téléchargé au premier lancement).
+const postgresDSNEnv = "TRACKKER_TEST_POSTGRES_DSN"
+
+var postgres struct {
+	once     sync.Once
+	dsn      string
+	err      error
+	embedded *embeddedpostgres.EmbeddedPostgres
+	dir      string
+	schemas  int
+	mu       sync.Mutex
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if postgres.embedded != nil {
+		_ = postgres.embedded.Stop()
+	}
+	if postgres.dir != "" {
+		_ = os.RemoveAll(postgres.dir)
+	}
+	os.Exit(code)
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// postgresDSN retourne la connexion à la base de test, le test est ignoré si aucune base n'est disponible
+func postgresDSN(t *testing.T) string {
+	t.Helper()
+	postgres.once.Do(func() {
+		if dsn := os.Getenv(postgresDSNEnv); dsn != "" {
+			postgres.dsn = dsn
+			return
+		}
+
+		dir, err := os.MkdirTemp("", "trackker-postgres")
+		if err != nil {
+			postgres.err = err
+			return
+		}
+		postgres.dir = dir
+		conf := embeddedpostgres.DefaultConfig().
+			Port(15432).
+			RuntimePath(dir).
+			Logger(io.Discard)
+		embedded := embeddedpostgres.NewDatabase(conf)
+		if err := embedded.Start(); err != nil {
+			postgres.err = err
+			return
+		}
+		postgres.embedded = embedded
+		postgres.dsn = conf.GetConnectionURL() + "?sslmode=disable"
+	})
+	if postgres.err != nil {
+		t.Skipf("PostgreSQL unavailable (set %s to use an existing database): %v", postgresDSNEnv, postgres.err)
+	}
+	return postgres.dsn
+}
+
+// withSchema isole chaque test dans un schéma PostgreSQL dédié
+func withSchema(t *testing.T, dsn string) string {
+	t.Helper()
+
+	postgres.mu.Lock()
+	postgres.schemas++
+	schema := fmt.Sprintf("trackker_test_%d_%d", os.Getpid(), postgres.schemas)
+	postgres.mu.Unlock()
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if admin, err := sql.Open("pgx", dsn); err == nil {
+			_, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+			_ = admin.Close()
+		}
+	})
+
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
+
+// forEachStore exécute le test sur chaque implémentation de Store
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemory())
+	})
+
+	t.Run(database.SQLite, func(t *testing.T) {
+		conf := &config.Config{}
+		conf.Database.Driver = database.SQLite
+		conf.Database.Path = filepath.Join(t.TempDir(), "test.db")
+		runOnDatabase(t, conf, test)
+	})
+
+	t.Run(database.Postgres, func(t *testing.T) {
+		conf := &config.Config{}
+		conf.Database.Driver = database.Postgres
+		conf.Database.DSN = withSchema(t, postgresDSN(t))
+		runOnDatabase(t, conf, test)
+	})
+}
+
+func runOnDatabase(t *testing.T, conf *config.Config, test func(t *testing.T, store Store)) {
+	t.Helper()
+	err := database.UseDb(conf, func(db *sql.DB) error {
+		test(t, New(discardLogger(), db, conf.Database.Driver))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"parameters", `SELECT * FROM tracks WHERE id = ? AND name = ?`, `SELECT * FROM tracks WHERE id = $1 AND name = $2`},
+		{"string literal", `SELECT '?' FROM tracks WHERE id = ?`, `SELECT '?' FROM tracks WHERE id = $1`},
+		{"escaped quote", `SELECT 'it''s ?' WHERE a = ? AND b = '?'`, `SELECT 'it''s ?' WHERE a = $1 AND b = '?'`},
+		{"quoted identifier", `SELECT "what?" FROM t WHERE x = ?`, `SELECT "what?" FROM t WHERE x = $1`},
+		{"no parameter", `SELECT 1`, `SELECT 1`},
+	}
+
+	postgresRepo := &Repository{driver: database.Postgres}
+	sqliteRepo := &Repository{driver: database.SQLite}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgresRepo.rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if got := sqliteRepo.rebind(tt.query); got != tt.query {
+				t.Errorf("rebind(%q) with SQLite = %q, want unchanged", tt.query, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/model"
+	"fmt"
+)
+
+// AddScrobble ajoute une lecture à la file d'envoi du service
+func (r *Repository) AddScrobble(scrobble *model.Scrobble) error {
+	id, err := r.insert(`
+		INSERT INTO scrobbles (service, track_id, artist, title, album, duration, played_at, status, attempts, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, scrobble.Service, scrobble.TrackID, scrobble.Artist, scrobble.Title, scrobble.Album, scrobble.Duration,
+		scrobble.PlayedAt, scrobble.Status, scrobble.Attempts, scrobble.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting scrobble: %w", err)
+	}
+	scrobble.ID = id
+	return nil
+}
+
+// FindQueuedScrobbles retourne les lectures en attente d'envoi au service, de la plus ancienne à la plus récente
+func (r *Repository) FindQueuedScrobbles(service string, limit int) ([]*model.Scrobble, error) {
+	rows, err := r.query(`
+		SELECT id, service, track_id, artist, title, album, duration, played_at, status, attempts, error, created_at, sent_at
+		FROM scrobbles
+		WHERE service = ? AND status = ?
+		ORDER BY played_at, id
+		LIMIT ?
+	`, service, model.ScrobbleQueued, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scrobbles []*model.Scrobble
+	for rows.Next() {
+		var scrobble model.Scrobble
+		var album, scrobbleError sql.Null[string]
+		var sentAt sql.NullTime
+		err := rows.Scan(&scrobble.ID, &scrobble.Service, &scrobble.TrackID, &scrobble.Artist, &scrobble.Title, &album,
+			&scrobble.Duration, &scrobble.PlayedAt, &scrobble.Status, &scrobble.Attempts, &scrobbleError,
+			&scrobble.CreatedAt, &sentAt)
+		if err != nil {
+			return nil, err
+		}
+		scrobble.Album = nullToPtr(album)
+		scrobble.Error = nullToPtr(scrobbleError)
+		if sentAt.Valid {
+			scrobble.SentAt = &sentAt.Time
+		}
+		scrobbles = append(scrobbles, &scrobble)
+	}
+	return scrobbles, rows.Err()
+}
+
+// UpdateScrobble enregistre le résultat d'une tentative d'envoi
+func (r *Repository) UpdateScrobble(scrobble *model.Scrobble) error {
+	_, err := r.exec(`
+		UPDATE scrobbles SET status = ?, attempts = ?, error = ?, sent_at = ? WHERE id = ?
+	`, scrobble.Status, scrobble.Attempts, scrobble.Error, scrobble.SentAt, scrobble.ID)
+	if err != nil {
+		return fmt.Errorf("error updating scrobble %d: %w", scrobble.ID, err)
+	}
+	return nil
+}
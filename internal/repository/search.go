@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"djtracker/internal/database"
+	"djtracker/internal/model"
+	"djtracker/internal/utils"
+	"strings"
+)
+
+// SearchTracks recherche dans l'historique les tracks dont l'artiste, le titre, le chemin, le remix
+// ou l'album contiennent tous les mots de la requête (en début de mot, sans tenir compte des accents).
+// Les résultats sont triés par pertinence puis du plus récent au plus ancien.
+func (r *Repository) SearchTracks(query string, limit int) ([]*model.SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if r.driver == database.Postgres {
+		return r.searchTracksPostgres(terms, limit)
+	}
+
+	// Chaque mot est cité pour neutraliser la syntaxe FTS5, puis recherché en préfixe
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = `"` + term + `"*`
+	}
+
+	// Les colonnes artist, name, path, remix et album sont pondérées dans cet ordre
+	return r.findSearchResults(`
+		SELECT `+trackColumnsOf("t")+`, e.start, -bm25(tracks_fts, 10.0, 10.0, 1.0, 5.0, 3.0) AS score
+		FROM tracks_fts
+		JOIN tracks t ON t.id = tracks_fts.rowid
+		JOIN events e ON e.id = t.event_id
+		WHERE tracks_fts MATCH ?
+		ORDER BY score DESC, t.play_at DESC
+		LIMIT ?
+	`, strings.Join(match, " "), limit)
+}
+
+// searchTracksPostgres utilise la recherche plein texte de PostgreSQL sur la colonne search_text,
+// enregistrée sans accents (voir model.Track.SearchText) comme les termes de la requête
+func (r *Repository) searchTracksPostgres(terms []string, limit int) ([]*model.SearchResult, error) {
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = term + ":*"
+	}
+
+	return r.findSearchResults(`
+		SELECT `+trackColumnsOf("t")+`, e.start, ts_rank(to_tsvector('simple', coalesce(t.search_text, '')), to_tsquery('simple', ?)) AS score
+		FROM tracks t
+		JOIN events e ON e.id = t.event_id
+		WHERE to_tsvector('simple', coalesce(t.search_text, '')) @@ to_tsquery('simple', ?)
+		ORDER BY score DESC, t.play_at DESC
+		LIMIT ?
+	`, strings.Join(match, " & "), strings.Join(match, " & "), limit)
+}
+
+func (r *Repository) findSearchResults(query string, args ...any) ([]*model.SearchResult, error) {
+	rows, err := r.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*model.SearchResult
+	for rows.Next() {
+		result := &model.SearchResult{}
+		track, err := scanTrack(rowWithExtra{rows, []any{&result.EventStart, &result.Score}})
+		if err != nil {
+			return nil, err
+		}
+		result.Track = track
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// rowWithExtra permet de lire avec scanTrack des colonnes supplémentaires placées après celles de la track
+type rowWithExtra struct {
+	row   scanner
+	extra []any
+}
+
+func (r rowWithExtra) Scan(dest ...any) error {
+	return r.row.Scan(append(dest, r.extra...)...)
+}
+
+// searchTerms découpe la requête en mots sans accents ni ponctuation
+func searchTerms(query string) []string {
+	return utils.Words(utils.FoldDiacritics(query))
+}
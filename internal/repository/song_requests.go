@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"djtracker/internal/model"
+	"errors"
+	"fmt"
+)
+
+const songRequestColumns = `id, event_id, path, artist, title, guest, message, status, track_id, created_at, updated_at`
+
+// AddSongRequest enregistre une demande de morceau pour l'événement en cours
+func (r *Repository) AddSongRequest(request *model.SongRequest) error {
+	if r.event == nil {
+		return ErrNoEvent
+	}
+	id, err := r.insert(`
+		INSERT INTO song_requests (event_id, path, artist, title, guest, message, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.event.ID, request.Path, request.Artist, request.Title, request.Guest, request.Message,
+		request.Status, request.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting song request: %w", err)
+	}
+	request.ID = id
+	request.EventID = r.event.ID
+	return nil
+}
+
+// FindSongRequest retourne la demande correspondant à l'identifiant, nil si elle n'existe pas
+func (r *Repository) FindSongRequest(id int64) (*model.SongRequest, error) {
+	request, err := scanSongRequest(r.queryRow(`
+		SELECT `+songRequestColumns+` FROM song_requests WHERE id = ?
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// FindSongRequests retourne les demandes d'un événement, de la plus ancienne à la plus récente
+func (r *Repository) FindSongRequests(eventID int64) ([]*model.SongRequest, error) {
+	rows, err := r.query(`
+		SELECT `+songRequestColumns+` FROM song_requests WHERE event_id = ? ORDER BY created_at, id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*model.SongRequest
+	for rows.Next() {
+		request, err := scanSongRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// UpdateSongRequest enregistre le nouvel état d'une demande
+func (r *Repository) UpdateSongRequest(request *model.SongRequest) error {
+	_, err := r.exec(`
+		UPDATE song_requests SET status = ?, track_id = ?, updated_at = ? WHERE id = ?
+	`, request.Status, request.TrackID, request.UpdatedAt, request.ID)
+	if err != nil {
+		return fmt.Errorf("error updating song request %d: %w", request.ID, err)
+	}
+	return nil
+}
+
+func scanSongRequest(row scanner) (*model.SongRequest, error) {
+	var request model.SongRequest
+	var artist, guest, message sql.Null[string]
+	var trackID sql.Null[int64]
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&request.ID, &request.EventID, &request.Path, &artist, &request.Title, &guest, &message,
+		&request.Status, &trackID, &request.CreatedAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Artist = nullToPtr(artist)
+	request.Guest = nullToPtr(guest)
+	request.Message = nullToPtr(message)
+	request.TrackID = nullToPtr(trackID)
+	if updatedAt.Valid {
+		request.UpdatedAt = &updatedAt.Time
+	}
+	return &request, nil
+}
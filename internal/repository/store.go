@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"djtracker/internal/model"
+	"errors"
+	"time"
+)
+
+// ErrNoEvent est retournée lorsqu'aucun événement n'a été préparé (voir EventStore.PrepareEvent)
+var ErrNoEvent = errors.New("no current event, PrepareEvent must be called first")
+
+// EventStore gère les événements (une soirée = un événement)
+type EventStore interface {
+	// PrepareEvent charge l'événement en cours ou en crée un nouveau
+	PrepareEvent() error
+	CurrentEvent() *model.Event
+	FindEvent(id int64) (*model.Event, error)
+	FindEvents(from, to time.Time) ([]*model.Event, error)
+	FindRecentEvents(limit int) ([]*model.Event, error)
+}
+
+// TrackStore gère l'historique des tracks jouées
+type TrackStore interface {
+	AddTrackToHistory(track *model.Track) error
+	AddTrackToEvent(eventID int64, track *model.Track) error
+	// MarkTrackSkipped marque une track comme passée à la date at
+	MarkTrackSkipped(id int64, at time.Time) error
+	FindLastTrack() (*model.Track, error)
+	FindTrack(id int64) (*model.Track, error)
+	FindRecentTracks(limit int) ([]*model.Track, error)
+	FindEventTracks(eventID int64) ([]*model.Track, error)
+	// FindEventPlays retourne les tracks de l'événement jouées depuis le fichier path (sans tenir compte de la casse)
+	// ou du même morceau (voir model.Track.Identity), hors tracks passées, de la plus récente à la plus ancienne.
+	// Un critère vide est ignoré.
+	FindEventPlays(eventID int64, path, identity string) ([]*model.Track, error)
+	FindTracksBetween(from, to time.Time) ([]*model.Track, error)
+	// FindLastTrackChange retourne la date de la dernière modification de l'historique
+	// (track jouée ou marquée comme passée), date zéro si l'historique est vide
+	FindLastTrackChange() (time.Time, error)
+}
+
+// FailureStore gère les entrées d'historique mises en quarantaine
+type FailureStore interface {
+	AddParseFailure(failure *model.ParseFailure) error
+	FindParseFailures(includeResolved bool) ([]*model.ParseFailure, error)
+	FindParseFailure(id int64) (*model.ParseFailure, error)
+	ResolveParseFailure(id int64, at time.Time) error
+}
+
+// SearchStore permet la recherche plein texte dans l'historique
+type SearchStore interface {
+	SearchTracks(query string, limit int) ([]*model.SearchResult, error)
+}
+
+// DeliveryStore garde l'historique des envois aux webhooks
+type DeliveryStore interface {
+	AddWebhookDelivery(delivery *model.WebhookDelivery) error
+	FindWebhookDeliveries(limit int) ([]*model.WebhookDelivery, error)
+}
+
+// ScrobbleStore garde la file des lectures à envoyer aux services de scrobbling
+type ScrobbleStore interface {
+	AddScrobble(scrobble *model.Scrobble) error
+	FindQueuedScrobbles(service string, limit int) ([]*model.Scrobble, error)
+	UpdateScrobble(scrobble *model.Scrobble) error
+}
+
+// RequestStore gère les demandes de morceaux du public
+type RequestStore interface {
+	// AddSongRequest enregistre une demande pour l'événement en cours
+	AddSongRequest(request *model.SongRequest) error
+	FindSongRequest(id int64) (*model.SongRequest, error)
+	FindSongRequests(eventID int64) ([]*model.SongRequest, error)
+	UpdateSongRequest(request *model.SongRequest) error
+}
+
+// LikeStore gère les likes du public sur les tracks
+type LikeStore interface {
+	// AddLike enregistre le like d'un navigateur, retourne false s'il avait déjà aimé la track
+	AddLike(trackID int64, token string, at time.Time) (bool, error)
+	CountLikes(trackID int64) (int, error)
+	// FindMostLikedTracks retourne les tracks jouées entre from et to ayant reçu le plus de likes
+	FindMostLikedTracks(from, to time.Time, limit int) ([]*model.LikedTrack, error)
+}
+
+// Store regroupe l'ensemble des données persistées par le tracker
+type Store interface {
+	EventStore
+	TrackStore
+	FailureStore
+	SearchStore
+	DeliveryStore
+	ScrobbleStore
+	RequestStore
+	LikeStore
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*Memory)(nil)
+)
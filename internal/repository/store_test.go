@@ -0,0 +1,630 @@
+package repository
+
+import (
+	"djtracker/internal/model"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// addTracks enregistre les tracks dans l'événement en cours, jouées à 4 minutes d'intervalle à partir de start
+func addTracks(t *testing.T, store Store, start time.Time, names ...string) []*model.Track {
+	t.Helper()
+	var tracks []*model.Track
+	for i, name := range names {
+		track := &model.Track{
+			Artist:   ptr("Daft Punk"),
+			Name:     name,
+			PlayAt:   start.Add(time.Duration(i) * 4 * time.Minute),
+			Path:     "/music/" + name + ".mp3",
+			Duration: 4 * time.Minute,
+			BPM:      ptr(123.5),
+		}
+		if err := store.AddTrackToHistory(track); err != nil {
+			t.Fatalf("AddTrackToHistory(%s): %v", name, err)
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func trackNames(tracks []*model.Track) []string {
+	names := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		names = append(names, track.Name)
+	}
+	return names
+}
+
+func assertNames(t *testing.T, what string, tracks []*model.Track, want ...string) {
+	t.Helper()
+	got := trackNames(tracks)
+	if len(got) != len(want) {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s = %v, want %v", what, got, want)
+		}
+	}
+}
+
+func prepareEvent(t *testing.T, store Store) *model.Event {
+	t.Helper()
+	if err := store.PrepareEvent(); err != nil {
+		t.Fatalf("PrepareEvent: %v", err)
+	}
+	event := store.CurrentEvent()
+	if event == nil {
+		t.Fatal("CurrentEvent() = nil after PrepareEvent")
+	}
+	return event
+}
+
+func TestStoreWithoutEvent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		if err := store.AddTrackToHistory(&model.Track{Name: "x", PlayAt: time.Now()}); err != ErrNoEvent {
+			t.Errorf("AddTrackToHistory without event = %v, want ErrNoEvent", err)
+		}
+		if _, err := store.FindLastTrack(); err != ErrNoEvent {
+			t.Errorf("FindLastTrack without event = %v, want ErrNoEvent", err)
+		}
+	})
+}
+
+func TestStoreEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+
+		// Un second appel le même jour reprend l'événement en cours
+		if again := prepareEvent(t, store); again.ID != event.ID {
+			t.Errorf("PrepareEvent created event %d, want current event %d", again.ID, event.ID)
+		}
+
+		found, err := store.FindEvent(event.ID)
+		if err != nil || found == nil || found.ID != event.ID {
+			t.Fatalf("FindEvent(%d) = %v, %v", event.ID, found, err)
+		}
+		if missing, err := store.FindEvent(event.ID + 100); err != nil || missing != nil {
+			t.Errorf("FindEvent(unknown) = %v, %v, want nil", missing, err)
+		}
+
+		recent, err := store.FindRecentEvents(5)
+		if err != nil || len(recent) != 1 {
+			t.Errorf("FindRecentEvents = %v, %v, want 1 event", recent, err)
+		}
+
+		events, err := store.FindEvents(event.Start.Add(-time.Hour), event.Start.Add(time.Hour))
+		if err != nil || len(events) != 1 {
+			t.Errorf("FindEvents = %v, %v, want 1 event", events, err)
+		}
+	})
+}
+
+func TestStoreTracks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+		start := time.Now().Truncate(time.Second).Add(-time.Hour)
+		if changed, err := store.FindLastTrackChange(); err != nil || !changed.IsZero() {
+			t.Errorf("FindLastTrackChange() on empty history = %s, %v, want zero", changed, err)
+		}
+		tracks := addTracks(t, store, start, "One More Time", "Aerodynamic", "Digital Love")
+		if changed, err := store.FindLastTrackChange(); err != nil || !changed.Equal(tracks[2].PlayAt) {
+			t.Errorf("FindLastTrackChange() = %s, %v, want last play %s", changed, err, tracks[2].PlayAt)
+		}
+
+		for _, track := range tracks {
+			if track.ID == 0 || track.EventID != event.ID {
+				t.Fatalf("stored track %q has id %d, event %d", track.Name, track.ID, track.EventID)
+			}
+		}
+
+		last, err := store.FindLastTrack()
+		if err != nil || last == nil || last.ID != tracks[2].ID {
+			t.Fatalf("FindLastTrack() = %v, %v, want track %d", last, err, tracks[2].ID)
+		}
+
+		found, err := store.FindTrack(tracks[0].ID)
+		if err != nil || found == nil {
+			t.Fatalf("FindTrack(%d) = %v, %v", tracks[0].ID, found, err)
+		}
+		if found.Name != "One More Time" || found.Artist == nil || *found.Artist != "Daft Punk" ||
+			found.Path != "/music/One More Time.mp3" || found.Duration != 4*time.Minute ||
+			found.BPM == nil || *found.BPM != 123.5 || !found.PlayAt.Equal(start) || found.Album != nil {
+			t.Errorf("FindTrack(%d) = %+v, fields do not round-trip", tracks[0].ID, found)
+		}
+		if missing, err := store.FindTrack(tracks[2].ID + 100); err != nil || missing != nil {
+			t.Errorf("FindTrack(unknown) = %v, %v, want nil", missing, err)
+		}
+
+		skippedAt := start.Add(30 * time.Minute)
+		if err := store.MarkTrackSkipped(tracks[1].ID, skippedAt); err != nil {
+			t.Fatalf("MarkTrackSkipped: %v", err)
+		}
+		if skipped, _ := store.FindTrack(tracks[1].ID); skipped == nil || !skipped.Skipped {
+			t.Errorf("track %d not marked as skipped", tracks[1].ID)
+		}
+		if changed, err := store.FindLastTrackChange(); err != nil || !changed.Equal(skippedAt) {
+			t.Errorf("FindLastTrackChange() after skip = %s, %v, want %s", changed, err, skippedAt)
+		}
+
+		recent, err := store.FindRecentTracks(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindRecentTracks", recent, "Digital Love", "One More Time")
+
+		recent, err = store.FindRecentTracks(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindRecentTracks(1)", recent, "Digital Love")
+
+		played, err := store.FindEventTracks(event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindEventTracks", played, "One More Time", "Aerodynamic", "Digital Love")
+
+		between, err := store.FindTracksBetween(start.Add(time.Minute), start.Add(10*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindTracksBetween", between, "Aerodynamic", "Digital Love")
+	})
+}
+
+// Les lectures précédentes d'un morceau sont trouvées par fichier ou par artiste et titre normalisés
+func TestStoreFindEventPlays(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+		start := time.Now().Truncate(time.Second).Add(-time.Hour)
+		tracks := addTracks(t, store, start, "One More Time", "Aerodynamic", "One more time!", "Digital Love")
+		if err := store.MarkTrackSkipped(tracks[3].ID, start.Add(13*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		// Même fichier sous un autre nom
+		renamed := &model.Track{Name: "Untitled", Path: "/MUSIC/aerodynamic.MP3", PlayAt: start.Add(20 * time.Minute)}
+		if err := store.AddTrackToHistory(renamed); err != nil {
+			t.Fatal(err)
+		}
+
+		identity := (&model.Track{Artist: ptr("daft punk"), Name: "One More Time"}).Identity()
+		tests := []struct {
+			name     string
+			path     string
+			identity string
+			want     []string
+		}{
+			{"no criteria", "", "", nil},
+			{"path ignoring case", "/music/Aerodynamic.mp3", "", []string{"Untitled", "Aerodynamic"}},
+			{"identity", "", identity, []string{"One more time!", "One More Time"}},
+			{"path or identity", "/music/Aerodynamic.mp3", identity, []string{"Untitled", "One more time!", "Aerodynamic", "One More Time"}},
+			{"skipped track", "/music/Digital Love.mp3", "", nil},
+			{"unknown", "/music/Genesis.mp3", "justice - genesis", nil},
+		}
+
+		for _, tt := range tests {
+			plays, err := store.FindEventPlays(event.ID, tt.path, tt.identity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := trackNames(plays); !slices.Equal(got, tt.want) && len(got)+len(tt.want) > 0 {
+				t.Errorf("%s: FindEventPlays = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+// Dates enregistrées avec des décalages horaires différents : bornes et tris sont exacts,
+// sans dépendre de la représentation des dates en base
+func TestStoreDatesAcrossTimeZones(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+	newYork := time.FixedZone("EDT", -4*3600)
+	tokyo := time.FixedZone("JST", 9*3600)
+
+	forEachStore(t, func(t *testing.T, store Store) {
+		prepareEvent(t, store)
+		plays := []*model.Track{
+			{Name: "Paris", PlayAt: time.Date(2024, 3, 31, 1, 30, 0, 0, paris)},
+			{Name: "New York", PlayAt: time.Date(2024, 3, 30, 21, 0, 0, 0, newYork)},
+			{Name: "Tokyo", PlayAt: time.Date(2024, 3, 31, 10, 15, 0, 0, tokyo)},
+		}
+		for _, track := range plays {
+			if err := store.AddTrackToHistory(track); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		recent, err := store.FindRecentTracks(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindRecentTracks(2)", recent, "Tokyo", "New York")
+
+		// Bornes incluses, exprimées dans d'autres fuseaux que les dates enregistrées
+		from := plays[1].PlayAt.In(tokyo)
+		to := plays[2].PlayAt.UTC()
+		between, err := store.FindTracksBetween(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindTracksBetween", between, "New York", "Tokyo")
+
+		between, err = store.FindTracksBetween(from.Add(time.Second), to.Add(-time.Second))
+		if err != nil || len(between) != 0 {
+			t.Errorf("FindTracksBetween(exclusive) = %v, %v, want none", trackNames(between), err)
+		}
+
+		// La track la plus aimée est hors période, la limite s'applique aux tracks de la période
+		for _, like := range []struct {
+			track *model.Track
+			token string
+		}{{plays[0], "a"}, {plays[0], "b"}, {plays[1], "a"}, {plays[2], "a"}} {
+			if _, err := store.AddLike(like.track.ID, like.token, like.track.PlayAt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		liked, err := store.FindMostLikedTracks(from, to, 1)
+		if err != nil || len(liked) != 1 || liked[0].Track.Name != "Tokyo" {
+			t.Fatalf("FindMostLikedTracks(limit 1) = %v, %v, want Tokyo", liked, err)
+		}
+		if !liked[0].Track.PlayAt.Equal(plays[2].PlayAt) {
+			t.Errorf("PlayAt = %s, want %s", liked[0].Track.PlayAt, plays[2].PlayAt)
+		}
+	})
+}
+
+func TestStoreSearchTracks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		prepareEvent(t, store)
+		addTracks(t, store, time.Now().Add(-time.Hour), "One More Time", "Aerodynamic")
+
+		results, err := store.SearchTracks("aero", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Track.Name != "Aerodynamic" {
+			t.Fatalf("SearchTracks(aero) = %v, want Aerodynamic", results)
+		}
+		if results[0].EventStart.IsZero() {
+			t.Error("search result has no event start")
+		}
+
+		if results, err := store.SearchTracks("daft", 1); err != nil || len(results) != 1 {
+			t.Errorf("SearchTracks(daft, 1) = %d results, %v, want 1", len(results), err)
+		}
+		if results, err := store.SearchTracks("justice", 10); err != nil || len(results) != 0 {
+			t.Errorf("SearchTracks(justice) = %d results, %v, want none", len(results), err)
+		}
+	})
+}
+
+// Les accents sont ignorés dans la requête comme dans l'historique, quel que soit le moteur
+func TestStoreSearchTracksIgnoresDiacritics(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		prepareEvent(t, store)
+		addTracks(t, store, time.Now().Add(-time.Hour), "L'Été Indien", "Ete 2000")
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"ete indien", []string{"L'Été Indien"}},
+			{"ÉTÉ INDIEN", []string{"L'Été Indien"}},
+			{"été 2000", []string{"Ete 2000"}},
+		}
+		for _, tt := range tests {
+			results, err := store.SearchTracks(tt.query, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, result := range results {
+				names = append(names, result.Track.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("SearchTracks(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		}
+	})
+}
+
+func TestStoreParseFailures(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+		failure := &model.ParseFailure{
+			Source:    "virtualdj",
+			File:      "/history/2026-10-19.m3u",
+			Kind:      model.FailureInvalidData,
+			Raw:       "#EXTVDJ:garbage",
+			Error:     "no tag",
+			CreatedAt: time.Now(),
+		}
+		if err := store.AddParseFailure(failure); err != nil {
+			t.Fatal(err)
+		}
+		if failure.ID == 0 || failure.EventID != event.ID {
+			t.Fatalf("stored failure has id %d, event %d", failure.ID, failure.EventID)
+		}
+
+		found, err := store.FindParseFailure(failure.ID)
+		if err != nil || found == nil || found.Raw != failure.Raw || found.ResolvedAt != nil {
+			t.Fatalf("FindParseFailure(%d) = %+v, %v", failure.ID, found, err)
+		}
+		if missing, err := store.FindParseFailure(failure.ID + 100); err != nil || missing != nil {
+			t.Errorf("FindParseFailure(unknown) = %+v, %v, want nil", missing, err)
+		}
+
+		if err := store.ResolveParseFailure(failure.ID, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if open, err := store.FindParseFailures(false); err != nil || len(open) != 0 {
+			t.Errorf("FindParseFailures(false) = %d, %v, want none", len(open), err)
+		}
+		if all, err := store.FindParseFailures(true); err != nil || len(all) != 1 || all[0].ResolvedAt == nil {
+			t.Errorf("FindParseFailures(true) = %v, %v, want the resolved failure", all, err)
+		}
+	})
+}
+
+// failureIDs identifiants des entrées en quarantaine, avec leur état de retraitement
+func failureIDs(failures []*model.ParseFailure) []string {
+	ids := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		state := "open"
+		if failure.ResolvedAt != nil {
+			state = "resolved"
+		}
+		ids = append(ids, fmt.Sprintf("%d:%s", failure.ID, state))
+	}
+	return ids
+}
+
+// Cycle de la commande failures : liste des entrées ouvertes, retraitement d'une partie
+// (track enregistrée dans l'événement d'origine puis entrée résolue), nouvelle liste
+func TestStoreParseFailuresReprocess(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+		createdAt := time.Now().Truncate(time.Second).Add(-time.Hour)
+		var failures []*model.ParseFailure
+		for i, raw := range []string{
+			"#EXTVDJ:<lastplaytime>1760900000</lastplaytime>",
+			"#EXTVDJ:garbage\nC:\\Music\\garbage.mp3",
+			"C:\\Music\\orphan.mp3",
+		} {
+			failure := &model.ParseFailure{
+				Source:    "virtualdj",
+				Kind:      model.FailureInvalidData,
+				Raw:       raw,
+				CreatedAt: createdAt.Add(time.Duration(i) * time.Minute),
+			}
+			if err := store.AddParseFailure(failure); err != nil {
+				t.Fatal(err)
+			}
+			failures = append(failures, failure)
+		}
+
+		open, err := store.FindParseFailures(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			fmt.Sprintf("%d:open", failures[0].ID),
+			fmt.Sprintf("%d:open", failures[1].ID),
+			fmt.Sprintf("%d:open", failures[2].ID),
+		}
+		if got := failureIDs(open); !slices.Equal(got, want) {
+			t.Fatalf("FindParseFailures(false) = %v, want %v", got, want)
+		}
+		if !open[0].CreatedAt.Equal(failures[0].CreatedAt) || open[0].EventID != event.ID {
+			t.Errorf("listed failure created at %s in event %d, want %s in event %d",
+				open[0].CreatedAt, open[0].EventID, failures[0].CreatedAt, event.ID)
+		}
+
+		// Retraitement de la deuxième entrée
+		resolvedAt := createdAt.Add(30 * time.Minute)
+		track := &model.Track{Name: "Recovered", Path: `C:\Music\garbage.mp3`, PlayAt: createdAt}
+		if err := store.AddTrackToEvent(open[1].EventID, track); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.ResolveParseFailure(open[1].ID, resolvedAt); err != nil {
+			t.Fatal(err)
+		}
+
+		open, err = store.FindParseFailures(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []string{fmt.Sprintf("%d:open", failures[0].ID), fmt.Sprintf("%d:open", failures[2].ID)}
+		if got := failureIDs(open); !slices.Equal(got, want) {
+			t.Errorf("FindParseFailures(false) after reprocess = %v, want %v", got, want)
+		}
+
+		all, err := store.FindParseFailures(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []string{
+			fmt.Sprintf("%d:open", failures[0].ID),
+			fmt.Sprintf("%d:resolved", failures[1].ID),
+			fmt.Sprintf("%d:open", failures[2].ID),
+		}
+		if got := failureIDs(all); !slices.Equal(got, want) {
+			t.Fatalf("FindParseFailures(true) after reprocess = %v, want %v", got, want)
+		}
+		if !all[1].ResolvedAt.Equal(resolvedAt) {
+			t.Errorf("ResolvedAt = %s, want %s", all[1].ResolvedAt, resolvedAt)
+		}
+
+		// L'entrée retraitée reste consultable, sa track est dans l'événement d'origine
+		resolved, err := store.FindParseFailure(failures[1].ID)
+		if err != nil || resolved == nil || resolved.ResolvedAt == nil {
+			t.Errorf("FindParseFailure(%d) = %+v, %v, want the resolved failure", failures[1].ID, resolved, err)
+		}
+		tracks, err := store.FindEventTracks(event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNames(t, "FindEventTracks", tracks, "Recovered")
+	})
+}
+
+func TestStoreWebhookDeliveries(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		for attempt := 1; attempt <= 3; attempt++ {
+			delivery := &model.WebhookDelivery{
+				Webhook:    "http://example.test/hook",
+				EventType:  "track",
+				TrackID:    ptr(int64(1)),
+				Attempt:    attempt,
+				StatusCode: ptr(503),
+				Success:    attempt == 3,
+				CreatedAt:  time.Now(),
+			}
+			if err := store.AddWebhookDelivery(delivery); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		deliveries, err := store.FindWebhookDeliveries(2)
+		if err != nil || len(deliveries) != 2 {
+			t.Fatalf("FindWebhookDeliveries(2) = %v, %v", deliveries, err)
+		}
+		if deliveries[0].Attempt != 3 || !deliveries[0].Success {
+			t.Errorf("most recent delivery = %+v, want attempt 3 succeeded", deliveries[0])
+		}
+	})
+}
+
+func TestStoreScrobbles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		playedAt := time.Now().Truncate(time.Second)
+		for _, service := range []string{"lastfm", "listenbrainz"} {
+			scrobble := &model.Scrobble{
+				Service:   service,
+				TrackID:   1,
+				Artist:    "Daft Punk",
+				Title:     "One More Time",
+				Duration:  5 * time.Minute,
+				PlayedAt:  playedAt,
+				Status:    model.ScrobbleQueued,
+				CreatedAt: time.Now(),
+			}
+			if err := store.AddScrobble(scrobble); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		queued, err := store.FindQueuedScrobbles("lastfm", 10)
+		if err != nil || len(queued) != 1 {
+			t.Fatalf("FindQueuedScrobbles(lastfm) = %v, %v", queued, err)
+		}
+		scrobble := queued[0]
+		if scrobble.Duration != 5*time.Minute || !scrobble.PlayedAt.Equal(playedAt) {
+			t.Errorf("queued scrobble = %+v, fields do not round-trip", scrobble)
+		}
+
+		sentAt := time.Now()
+		scrobble.Status = model.ScrobbleSent
+		scrobble.Attempts = 1
+		scrobble.SentAt = &sentAt
+		if err := store.UpdateScrobble(scrobble); err != nil {
+			t.Fatal(err)
+		}
+		if queued, err := store.FindQueuedScrobbles("lastfm", 10); err != nil || len(queued) != 0 {
+			t.Errorf("FindQueuedScrobbles(lastfm) after send = %d, %v, want none", len(queued), err)
+		}
+		if queued, err := store.FindQueuedScrobbles("listenbrainz", 10); err != nil || len(queued) != 1 {
+			t.Errorf("FindQueuedScrobbles(listenbrainz) = %d, %v, want 1", len(queued), err)
+		}
+	})
+}
+
+func TestStoreSongRequests(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		event := prepareEvent(t, store)
+		request := &model.SongRequest{
+			Path:      "/music/Aerodynamic.mp3",
+			Artist:    ptr("Daft Punk"),
+			Title:     "Aerodynamic",
+			Guest:     ptr("Alex"),
+			Status:    model.RequestPending,
+			CreatedAt: time.Now(),
+		}
+		if err := store.AddSongRequest(request); err != nil {
+			t.Fatal(err)
+		}
+		if request.ID == 0 || request.EventID != event.ID {
+			t.Fatalf("stored request has id %d, event %d", request.ID, request.EventID)
+		}
+
+		now := time.Now()
+		request.Status = model.RequestPlayed
+		request.TrackID = ptr(int64(42))
+		request.UpdatedAt = &now
+		if err := store.UpdateSongRequest(request); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := store.FindSongRequest(request.ID)
+		if err != nil || found == nil {
+			t.Fatalf("FindSongRequest(%d) = %v, %v", request.ID, found, err)
+		}
+		if found.Status != model.RequestPlayed || found.TrackID == nil || *found.TrackID != 42 ||
+			found.Guest == nil || *found.Guest != "Alex" || found.Message != nil {
+			t.Errorf("FindSongRequest(%d) = %+v, fields do not round-trip", request.ID, found)
+		}
+		if missing, err := store.FindSongRequest(request.ID + 100); err != nil || missing != nil {
+			t.Errorf("FindSongRequest(unknown) = %v, %v, want nil", missing, err)
+		}
+
+		requests, err := store.FindSongRequests(event.ID)
+		if err != nil || len(requests) != 1 {
+			t.Errorf("FindSongRequests(%d) = %v, %v, want 1 request", event.ID, requests, err)
+		}
+	})
+}
+
+func TestStoreLikes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		prepareEvent(t, store)
+		start := time.Now().Add(-time.Hour)
+		tracks := addTracks(t, store, start, "One More Time", "Aerodynamic")
+
+		likes := []struct {
+			track *model.Track
+			token string
+			added bool
+		}{
+			{tracks[0], "guest-a", true},
+			{tracks[0], "guest-a", false},
+			{tracks[1], "guest-a", true},
+			{tracks[1], "guest-b", true},
+		}
+		for _, like := range likes {
+			added, err := store.AddLike(like.track.ID, like.token, time.Now())
+			if err != nil || added != like.added {
+				t.Errorf("AddLike(%d, %s) = %v, %v, want %v", like.track.ID, like.token, added, err, like.added)
+			}
+		}
+
+		if count, err := store.CountLikes(tracks[1].ID); err != nil || count != 2 {
+			t.Errorf("CountLikes(%d) = %d, %v, want 2", tracks[1].ID, count, err)
+		}
+
+		liked, err := store.FindMostLikedTracks(start.Add(-time.Minute), time.Now(), 10)
+		if err != nil || len(liked) != 2 {
+			t.Fatalf("FindMostLikedTracks = %v, %v, want 2 tracks", liked, err)
+		}
+		if liked[0].Track.Name != "Aerodynamic" || liked[0].Likes != 2 || liked[1].Likes != 1 {
+			t.Errorf("FindMostLikedTracks = [%s: %d, %s: %d], want Aerodynamic first with 2 likes",
+				liked[0].Track.Name, liked[0].Likes, liked[1].Track.Name, liked[1].Likes)
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package analytics
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"sort"
+	"time"
+)
+
+// Analytics calcule les statistiques des sets à partir de l'historique
+type Analytics struct {
+	store repository.Store
+}
+
+func New(store repository.Store) *Analytics {
+	return &Analytics{
+		store: store,
+	}
+}
+
+// ArtistCount nombre de lectures d'un artiste
+type ArtistCount struct {
+	Artist string
+	Plays  int
+}
+
+// Transition décrit l'enchaînement entre deux tracks.
+// Gap est négatif lorsque la track suivante démarre avant la fin de la précédente (mix).
+type Transition struct {
+	From     *model.Track
+	To       *model.Track
+	Interval time.Duration
+	Gap      time.Duration
+}
+
+// EventStats statistiques d'un événement
+type EventStats struct {
+	Event            *model.Event
+	TrackCount       int
+	SkippedCount     int
+	SetLength        time.Duration
+	AverageTrackTime time.Duration
+	TopArtists       []ArtistCount
+	Transitions      []Transition
+	LongestGap       *Transition
+}
+
+// TrackStats lectures d'un même morceau (même artiste et titre)
+type TrackStats struct {
+	Track        *model.Track
+	Plays        int
+	EventsPlayed int
+	FirstPlay    time.Time
+	LastPlay     time.Time
+	// Frequency part des événements de la période où le morceau a été joué
+	Frequency float64
+}
+
+// Stats statistiques sur l'ensemble des événements d'une période
+type Stats struct {
+	From       time.Time
+	To         time.Time
+	EventCount int
+	TrackCount int
+	TopTracks  []*TrackStats
+	TopArtists []ArtistCount
+	// MostLiked tracks ayant reçu le plus de likes du public
+	MostLiked []*model.LikedTrack
+}
+
+// EventStats calcule les statistiques d'un événement, nil s'il n'existe pas
+func (a *Analytics) EventStats(eventID int64, limit int) (*EventStats, error) {
+	event, err := a.store.FindEvent(eventID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	all, err := a.store.FindEventTracks(eventID)
+	if err != nil {
+		return nil, err
+	}
+	tracks := playedTracks(all)
+
+	stats := &EventStats{
+		Event:        event,
+		TrackCount:   len(tracks),
+		SkippedCount: len(all) - len(tracks),
+		TopArtists:   topArtists(tracks, limit),
+	}
+	if len(tracks) == 0 {
+		return stats, nil
+	}
+
+	first, last := tracks[0], tracks[len(tracks)-1]
+	stats.SetLength = last.PlayAt.Add(last.Duration).Sub(first.PlayAt)
+
+	var played time.Duration
+	longest := -1
+	for i := 1; i < len(tracks); i++ {
+		previous, current := tracks[i-1], tracks[i]
+		transition := Transition{
+			From:     previous,
+			To:       current,
+			Interval: current.PlayAt.Sub(previous.PlayAt),
+			Gap:      current.PlayAt.Sub(previous.PlayAt.Add(previous.Duration)),
+		}
+		stats.Transitions = append(stats.Transitions, transition)
+		played += transition.Interval
+
+		if longest < 0 || transition.Gap > stats.Transitions[longest].Gap {
+			longest = len(stats.Transitions) - 1
+		}
+	}
+	if longest >= 0 {
+		stats.LongestGap = &stats.Transitions[longest]
+	}
+
+	// La dernière track est comptée pour sa durée, faute de track suivante
+	played += last.Duration
+	stats.AverageTrackTime = played / time.Duration(len(tracks))
+	return stats, nil
+}
+
+// Stats calcule les statistiques de tous les événements entre from et to
+func (a *Analytics) Stats(from, to time.Time, limit int) (*Stats, error) {
+	events, err := a.store.FindEvents(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := a.store.FindTracksBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	tracks = playedTracks(tracks)
+
+	mostLiked, err := a.store.FindMostLikedTracks(from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stats{
+		From:       from,
+		To:         to,
+		EventCount: len(events),
+		TrackCount: len(tracks),
+		TopTracks:  topTracks(tracks, len(events), limit),
+		TopArtists: topArtists(tracks, limit),
+		MostLiked:  mostLiked,
+	}, nil
+}
+
+// TrackStats retourne les lectures du morceau correspondant au chemin entre from et to, nil s'il n'a pas été joué
+func (a *Analytics) TrackStats(path string, from, to time.Time) (*TrackStats, error) {
+	events, err := a.store.FindEvents(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := a.store.FindTracksBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	tracks = playedTracks(tracks)
+
+	identity := ""
+	for _, track := range tracks {
+		if track.Path == path {
+			identity = track.Identity()
+			break
+		}
+	}
+	if identity == "" {
+		return nil, nil
+	}
+
+	var plays []*model.Track
+	for _, track := range tracks {
+		if track.Path == path || track.Identity() == identity {
+			plays = append(plays, track)
+		}
+	}
+
+	all := topTracks(plays, len(events), 1)
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all[0], nil
+}
+
+// playedTracks écarte les tracks passées (voir model.Track.Skipped), absentes des statistiques
+func playedTracks(tracks []*model.Track) []*model.Track {
+	played := make([]*model.Track, 0, len(tracks))
+	for _, track := range tracks {
+		if !track.Skipped {
+			played = append(played, track)
+		}
+	}
+	return played
+}
+
+func topArtists(tracks []*model.Track, limit int) []ArtistCount {
+	counts := make(map[string]int)
+	for _, track := range tracks {
+		if track.Artist != nil {
+			counts[*track.Artist]++
+		}
+	}
+
+	artists := make([]ArtistCount, 0, len(counts))
+	for artist, plays := range counts {
+		artists = append(artists, ArtistCount{Artist: artist, Plays: plays})
+	}
+	sort.Slice(artists, func(i, j int) bool {
+		if artists[i].Plays != artists[j].Plays {
+			return artists[i].Plays > artists[j].Plays
+		}
+		return artists[i].Artist < artists[j].Artist
+	})
+	return truncate(artists, limit)
+}
+
+// topTracks regroupe les lectures par morceau (voir model.Track.Identity), du plus joué au moins joué
+func topTracks(tracks []*model.Track, eventCount, limit int) []*TrackStats {
+	byIdentity := make(map[string]*TrackStats)
+	events := make(map[string]map[int64]bool)
+
+	for _, track := range tracks {
+		identity := track.Identity()
+		stats, ok := byIdentity[identity]
+		if !ok {
+			stats = &TrackStats{Track: track, FirstPlay: track.PlayAt}
+			byIdentity[identity] = stats
+			events[identity] = make(map[int64]bool)
+		}
+
+		stats.Plays++
+		events[identity][track.EventID] = true
+		if track.PlayAt.Before(stats.FirstPlay) {
+			stats.FirstPlay = track.PlayAt
+		}
+		if track.PlayAt.After(stats.LastPlay) {
+			stats.LastPlay = track.PlayAt
+			stats.Track = track
+		}
+	}
+
+	result := make([]*TrackStats, 0, len(byIdentity))
+	for identity, stats := range byIdentity {
+		stats.EventsPlayed = len(events[identity])
+		if eventCount > 0 {
+			stats.Frequency = float64(stats.EventsPlayed) / float64(eventCount)
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Plays != result[j].Plays {
+			return result[i].Plays > result[j].Plays
+		}
+		return result[i].LastPlay.After(result[j].LastPlay)
+	})
+	return truncate(result, limit)
+}
+
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,287 @@
+package analytics
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"slices"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC)
+
+// play track jouée offset après le début de la soirée
+type play struct {
+	artist   string
+	name     string
+	offset   time.Duration
+	duration time.Duration
+	skipped  bool
+}
+
+func addPlays(t *testing.T, store *repository.Memory, event *model.Event, plays ...play) []*model.Track {
+	t.Helper()
+	var tracks []*model.Track
+	for _, p := range plays {
+		track := &model.Track{
+			Name:     p.name,
+			Path:     "/music/" + p.name + ".mp3",
+			PlayAt:   event.Start.Add(p.offset),
+			Duration: p.duration,
+		}
+		if p.artist != "" {
+			artist := p.artist
+			track.Artist = &artist
+		}
+		if err := store.AddTrackToEvent(event.ID, track); err != nil {
+			t.Fatal(err)
+		}
+		if p.skipped {
+			if err := store.MarkTrackSkipped(track.ID, track.PlayAt.Add(10*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func TestEventStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		plays       []play
+		tracks      int
+		skipped     int
+		setLength   time.Duration
+		average     time.Duration
+		gaps        []time.Duration
+		longestGap  int // index de la transition la plus longue, -1 si aucune
+		topArtist   string
+		artistPlays int
+	}{
+		{
+			name:       "empty event",
+			longestGap: -1,
+		},
+		{
+			name:        "single track",
+			plays:       []play{{"Daft Punk", "One More Time", 0, 4 * time.Minute, false}},
+			tracks:      1,
+			setLength:   4 * time.Minute,
+			average:     4 * time.Minute,
+			longestGap:  -1,
+			topArtist:   "Daft Punk",
+			artistPlays: 1,
+		},
+		{
+			// Mix : la deuxième track démarre une minute avant la fin de la première
+			name: "overlapping tracks",
+			plays: []play{
+				{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+				{"Justice", "D.A.N.C.E.", 4 * time.Minute, 5 * time.Minute, false},
+				{"Daft Punk", "Aerodynamic", 10 * time.Minute, 3 * time.Minute, false},
+			},
+			tracks:      3,
+			setLength:   13 * time.Minute,
+			average:     13 * time.Minute / 3,
+			gaps:        []time.Duration{-time.Minute, time.Minute},
+			longestGap:  1,
+			topArtist:   "Daft Punk",
+			artistPlays: 2,
+		},
+		{
+			// Toutes les transitions sont mixées : la plus longue est la moins négative
+			name: "only overlapping tracks",
+			plays: []play{
+				{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+				{"Daft Punk", "Aerodynamic", 4 * time.Minute, 5 * time.Minute, false},
+				{"Daft Punk", "Digital Love", 8*time.Minute + 30*time.Second, 5 * time.Minute, false},
+			},
+			tracks:      3,
+			setLength:   13*time.Minute + 30*time.Second,
+			average:     (13*time.Minute + 30*time.Second) / 3,
+			gaps:        []time.Duration{-time.Minute, -30 * time.Second},
+			longestGap:  1,
+			topArtist:   "Daft Punk",
+			artistPlays: 3,
+		},
+		{
+			name: "skipped tracks excluded",
+			plays: []play{
+				{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+				{"Justice", "Genesis", 10 * time.Second, 4 * time.Minute, true},
+				{"Daft Punk", "Aerodynamic", 5 * time.Minute, 3 * time.Minute, false},
+			},
+			tracks:      2,
+			skipped:     1,
+			setLength:   8 * time.Minute,
+			average:     4 * time.Minute,
+			gaps:        []time.Duration{0},
+			longestGap:  0,
+			topArtist:   "Daft Punk",
+			artistPlays: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repository.NewMemory()
+			event := store.AddEvent(start)
+			addPlays(t, store, event, tt.plays...)
+
+			stats, err := New(store).EventStats(event.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.TrackCount != tt.tracks || stats.SkippedCount != tt.skipped {
+				t.Errorf("tracks = %d (%d skipped), want %d (%d skipped)", stats.TrackCount, stats.SkippedCount, tt.tracks, tt.skipped)
+			}
+			if stats.SetLength != tt.setLength {
+				t.Errorf("SetLength = %s, want %s", stats.SetLength, tt.setLength)
+			}
+			if stats.AverageTrackTime != tt.average {
+				t.Errorf("AverageTrackTime = %s, want %s", stats.AverageTrackTime, tt.average)
+			}
+
+			if len(stats.Transitions) != len(tt.gaps) {
+				t.Fatalf("%d transitions, want %d", len(stats.Transitions), len(tt.gaps))
+			}
+			for i, gap := range tt.gaps {
+				transition := stats.Transitions[i]
+				if transition.Gap != gap {
+					t.Errorf("transition %d gap = %s, want %s", i, transition.Gap, gap)
+				}
+				if transition.From.Skipped || transition.To.Skipped {
+					t.Errorf("transition %d involves a skipped track", i)
+				}
+			}
+
+			if tt.longestGap < 0 {
+				if stats.LongestGap != nil {
+					t.Errorf("LongestGap = %+v, want nil", stats.LongestGap)
+				}
+			} else if stats.LongestGap == nil || stats.LongestGap != &stats.Transitions[tt.longestGap] {
+				t.Errorf("LongestGap = %+v, want transition %d", stats.LongestGap, tt.longestGap)
+			}
+
+			if tt.topArtist == "" {
+				if len(stats.TopArtists) != 0 {
+					t.Errorf("TopArtists = %v, want none", stats.TopArtists)
+				}
+			} else if len(stats.TopArtists) == 0 || stats.TopArtists[0] != (ArtistCount{tt.topArtist, tt.artistPlays}) {
+				t.Errorf("TopArtists = %v, want %s first with %d plays", stats.TopArtists, tt.topArtist, tt.artistPlays)
+			}
+		})
+	}
+}
+
+func TestEventStatsUnknownEvent(t *testing.T) {
+	stats, err := New(repository.NewMemory()).EventStats(42, 10)
+	if err != nil || stats != nil {
+		t.Errorf("EventStats(unknown) = %+v, %v, want nil", stats, err)
+	}
+}
+
+// Trois soirées : les lectures d'un même morceau sont regroupées quelles que soient
+// la casse et la ponctuation, les tracks passées ne sont pas comptées
+func TestStats(t *testing.T) {
+	store := repository.NewMemory()
+	first := store.AddEvent(start)
+	second := store.AddEvent(start.AddDate(0, 0, 7))
+	third := store.AddEvent(start.AddDate(0, 0, 14))
+
+	addPlays(t, store, first,
+		play{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+		play{"Justice", "Genesis", 5 * time.Minute, 4 * time.Minute, false},
+	)
+	addPlays(t, store, second,
+		play{"DAFT PUNK", "One more time!", 0, 5 * time.Minute, false},
+		play{"Justice", "Genesis", 10 * time.Second, 4 * time.Minute, true},
+		play{"Air", "La Femme d'Argent", 5 * time.Minute, 7 * time.Minute, false},
+	)
+	addPlays(t, store, third,
+		play{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+		play{"Air", "Sexy Boy", 5 * time.Minute, 5 * time.Minute, false},
+	)
+
+	stats, err := New(store).Stats(start, start.AddDate(0, 1, 0), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.EventCount != 3 || stats.TrackCount != 6 {
+		t.Errorf("stats = %d events, %d tracks, want 3 events, 6 tracks", stats.EventCount, stats.TrackCount)
+	}
+
+	if len(stats.TopTracks) != 4 {
+		t.Fatalf("%d top tracks, want 4", len(stats.TopTracks))
+	}
+	top := stats.TopTracks[0]
+	if top.Plays != 3 || top.EventsPlayed != 3 || top.Frequency != 1 {
+		t.Errorf("top track = %d plays in %d events (%.2f), want 3 plays in 3 events (1.00)", top.Plays, top.EventsPlayed, top.Frequency)
+	}
+	if !top.FirstPlay.Equal(first.Start) || !top.LastPlay.Equal(third.Start) || top.Track.EventID != third.ID {
+		t.Errorf("top track plays from %s to %s (event %d), want %s to %s (event %d)",
+			top.FirstPlay, top.LastPlay, top.Track.EventID, first.Start, third.Start, third.ID)
+	}
+	// Une seule lecture chacun : le plus récent en premier
+	var names []string
+	for _, track := range stats.TopTracks[1:] {
+		names = append(names, track.Track.Name)
+		if track.Plays != 1 || track.Frequency != 1.0/3 {
+			t.Errorf("%s = %d plays (%.2f), want 1 play (0.33)", track.Track.Name, track.Plays, track.Frequency)
+		}
+	}
+	if want := []string{"Sexy Boy", "La Femme d'Argent", "Genesis"}; !slices.Equal(names, want) {
+		t.Errorf("top tracks = %v, want %v", names, want)
+	}
+
+	// Égalité départagée par ordre alphabétique, l'artiste n'est pas normalisé
+	want := []ArtistCount{{"Air", 2}, {"Daft Punk", 2}, {"DAFT PUNK", 1}, {"Justice", 1}}
+	if len(stats.TopArtists) != len(want) {
+		t.Fatalf("TopArtists = %v, want %v", stats.TopArtists, want)
+	}
+	for i := range want {
+		if stats.TopArtists[i] != want[i] {
+			t.Errorf("TopArtists = %v, want %v", stats.TopArtists, want)
+			break
+		}
+	}
+
+	limited, err := New(store).Stats(start, start.AddDate(0, 1, 0), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited.TopTracks) != 2 || len(limited.TopArtists) != 2 || limited.TopArtists[1].Artist != "Daft Punk" {
+		t.Errorf("limited stats = %d tracks, artists %v, want 2 tracks and Air, Daft Punk", len(limited.TopTracks), limited.TopArtists)
+	}
+}
+
+func TestTrackStats(t *testing.T) {
+	store := repository.NewMemory()
+	first := store.AddEvent(start)
+	second := store.AddEvent(start.AddDate(0, 0, 7))
+	addPlays(t, store, first,
+		play{"Daft Punk", "One More Time", 0, 5 * time.Minute, false},
+		play{"Daft Punk", "One More Time", time.Hour, 5 * time.Minute, false},
+	)
+	// Autre morceau du même artiste, puis lecture passée : ni l'un ni l'autre ne compte
+	addPlays(t, store, second,
+		play{"Daft Punk", "One More Time (Radio Edit)", 0, 4 * time.Minute, false},
+		play{"Daft Punk", "One More Time", time.Hour, 5 * time.Minute, true},
+	)
+
+	analytics := New(store)
+	stats, err := analytics.TrackStats("/music/One More Time.mp3", start, start.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats == nil || stats.Plays != 2 || stats.EventsPlayed != 1 || stats.Frequency != 0.5 {
+		t.Fatalf("TrackStats = %+v, want 2 plays in 1 of 2 events", stats)
+	}
+
+	if stats, err := analytics.TrackStats("/music/unknown.mp3", start, start.AddDate(0, 1, 0)); err != nil || stats != nil {
+		t.Errorf("TrackStats(unknown) = %+v, %v, want nil", stats, err)
+	}
+}
@@ -10,6 +10,7 @@ type Broadcaster[T any] struct {
 	mu      sync.RWMutex
 	nextId  int
 	clients map[int]chan T
+	closed  bool
 }
 
 func NewBroadcaster[T any](log *slog.Logger) *Broadcaster[T] {
@@ -24,6 +25,11 @@ func (b *Broadcaster[T]) Subscribe(buffer int) (chan T, func()) {
 	defer b.mu.Unlock()
 
 	channel := make(chan T, buffer)
+	if b.closed {
+		close(channel)
+		return channel, func() {}
+	}
+
 	id := b.nextId
 	b.clients[id] = channel
 	b.nextId++
@@ -53,3 +59,48 @@ func (b *Broadcaster[T]) Broadcast(data T) {
 		ch <- data
 	}
 }
+
+// BroadcastLatest envoie la donnée sans jamais bloquer : si un client n'a pas lu la précédente,
+// elle est remplacée. Réservé aux états dont seule la dernière valeur compte (compteurs...).
+func (b *Broadcaster[T]) BroadcastLatest(data T) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.clients {
+		select {
+		case ch <- data:
+			continue
+		default:
+		}
+		// Channel plein : la valeur en attente est périmée
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// Count retourne le nombre de clients actuellement abonnés
+func (b *Broadcaster[T]) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
+}
+
+// Close ferme les channels de tous les clients abonnés.
+// Les abonnements suivants reçoivent directement un channel fermé.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, ch := range b.clients {
+		close(ch)
+		delete(b.clients, id)
+	}
+	b.closed = true
+	b.log.Info("Broadcaster closed")
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/service/library"
+	"djtracker/internal/utils"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileReadTimeout durée maximale d'attente de la lecture d'un fichier non indexé :
+// Enrich est appelé par la boucle du tracker, un disque lent ou un partage réseau
+// injoignable ne doit pas retarder l'enregistrement de la track
+const fileReadTimeout = 2 * time.Second
+
+// Champs complétés par l'Enricher, utilisés comme clés de model.MetadataSources
+const (
+	fieldArtist   = "artist"
+	fieldTitle    = "title"
+	fieldAlbum    = "album"
+	fieldDuration = "duration"
+	fieldBPM      = "bpm"
+)
+
+// Enricher complète les métadonnées absentes de l'historique à partir de l'index de la bibliothèque,
+// ou des tags du fichier s'il n'est pas indexé
+type Enricher struct {
+	log         *slog.Logger
+	library     *library.Library
+	readTimeout time.Duration
+}
+
+func NewEnricher(log *slog.Logger, library *library.Library) *Enricher {
+	return &Enricher{
+		log:         log,
+		library:     library,
+		readTimeout: fileReadTimeout,
+	}
+}
+
+// Enrich remplit les champs manquants de la track et note l'origine de chaque champ renseigné
+func (e *Enricher) Enrich(track *model.Track) {
+	sources := model.MetadataSources{}
+	for field, present := range presentFields(track) {
+		if present {
+			sources[field] = model.SourceHistory
+		}
+	}
+	track.Sources = sources
+
+	if isComplete(track) {
+		return
+	}
+
+	var entry *library.Entry
+	if e.library != nil {
+		entry = e.library.Lookup(track.Path)
+	}
+	if entry != nil {
+		// L'index contient déjà les tags et la durée lus dans le fichier
+		e.fill(track, entry, model.SourceLibrary)
+	} else if entry = e.readFile(track.Path); entry != nil {
+		e.fill(track, entry, model.SourceFile)
+	}
+
+	// Dernier recours : le nom du fichier sert de titre
+	if track.Name == "" && track.Path != "" {
+		name := strings.ReplaceAll(track.Path, `\`, "/")
+		name = name[strings.LastIndex(name, "/")+1:]
+		track.Name = strings.TrimSuffix(name, filepath.Ext(name))
+		sources[fieldTitle] = model.SourceFilename
+	}
+
+	if len(sources) > 0 {
+		e.log.Debug("Track metadata enriched", "path", track.Path, "sources", sources)
+	}
+}
+
+// readFile lit les tags et la durée du fichier, nil s'il est absent ou si la lecture dépasse readTimeout.
+// Une lecture abandonnée se termine en arrière-plan.
+func (e *Enricher) readFile(path string) *library.Entry {
+	if path == "" {
+		return nil
+	}
+
+	result := make(chan *library.Entry, 1)
+	go func() {
+		if !utils.Exists(path) {
+			result <- nil
+			return
+		}
+		result <- library.ReadEntry(path)
+	}()
+
+	select {
+	case entry := <-result:
+		return entry
+	case <-time.After(e.readTimeout):
+		e.log.Warn("Track file read timed out, metadata not enriched", "path", path, "timeout", e.readTimeout)
+		return nil
+	}
+}
+
+func (e *Enricher) fill(track *model.Track, entry *library.Entry, source string) {
+	if track.Artist == nil && entry.Artist != "" {
+		track.Artist = utils.EmptyStringNil(entry.Artist)
+		track.Sources[fieldArtist] = source
+	}
+	if track.Name == "" && entry.Title != "" {
+		track.Name = entry.Title
+		track.Sources[fieldTitle] = source
+	}
+	if track.Album == nil && entry.Album != "" {
+		track.Album = utils.EmptyStringNil(entry.Album)
+		track.Sources[fieldAlbum] = source
+	}
+	if track.Duration <= 0 && entry.Duration > 0 {
+		track.Duration = entry.Duration
+		track.Sources[fieldDuration] = source
+	}
+	if track.BPM == nil && entry.BPM > 0 {
+		track.BPM = utils.ZeroNil(entry.BPM)
+		track.Sources[fieldBPM] = source
+	}
+}
+
+func presentFields(track *model.Track) map[string]bool {
+	return map[string]bool{
+		fieldArtist:   track.Artist != nil,
+		fieldTitle:    track.Name != "",
+		fieldAlbum:    track.Album != nil,
+		fieldDuration: track.Duration > 0,
+		fieldBPM:      track.BPM != nil,
+	}
+}
+
+func isComplete(track *model.Track) bool {
+	for _, present := range presentFields(track) {
+		if !present {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"djtracker/internal/model"
+	"djtracker/internal/service/library"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeWAV écrit un fichier WAV mono 8 bits à 1000 Hz, sans tags, de la durée demandée
+func writeWAV(t *testing.T, path string, duration time.Duration) {
+	t.Helper()
+	const rate = 1000
+	data := make([]byte, int(duration.Seconds()*rate))
+
+	var buf []byte
+	buf = append(buf, "RIFF"...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(4+24+8+len(data)))
+	buf = append(buf, "WAVEfmt "...)
+	buf = binary.LittleEndian.AppendUint32(buf, 16)
+	buf = binary.LittleEndian.AppendUint16(buf, 1)
+	buf = binary.LittleEndian.AppendUint16(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, rate)
+	buf = binary.LittleEndian.AppendUint32(buf, rate)
+	buf = binary.LittleEndian.AppendUint16(buf, 1)
+	buf = binary.LittleEndian.AppendUint16(buf, 8)
+	buf = append(buf, "data"...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Une track indexée est complétée depuis la bibliothèque, sans relire le fichier
+func TestEnrichPrefersLibrary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Aerodynamic.wav")
+	writeWAV(t, path, 3*time.Second)
+
+	lib := library.New(discardLogger(), []string{dir})
+	if err := lib.Index(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Fichier remplacé après l'indexation : seule la durée indexée doit être utilisée
+	writeWAV(t, path, 5*time.Second)
+
+	track := &model.Track{Path: path}
+	NewEnricher(discardLogger(), lib).Enrich(track)
+
+	if track.Duration != 3*time.Second || track.Sources[fieldDuration] != model.SourceLibrary {
+		t.Errorf("duration = %s from %q, want 3s from the library", track.Duration, track.Sources[fieldDuration])
+	}
+	if track.Name != "Aerodynamic" || track.Sources[fieldTitle] != model.SourceFilename {
+		t.Errorf("name = %q from %q, want the file name", track.Name, track.Sources[fieldTitle])
+	}
+}
+
+// Une track absente de l'index est complétée depuis le fichier
+func TestEnrichReadsUnindexedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Aerodynamic.wav")
+	writeWAV(t, path, 2*time.Second)
+
+	lib := library.New(discardLogger(), nil)
+	track := &model.Track{Path: path}
+	NewEnricher(discardLogger(), lib).Enrich(track)
+
+	if track.Duration != 2*time.Second || track.Sources[fieldDuration] != model.SourceFile {
+		t.Errorf("duration = %s from %q, want 2s from the file", track.Duration, track.Sources[fieldDuration])
+	}
+}
+
+// Un fichier absent ne fournit aucune métadonnée
+func TestEnrichMissingFile(t *testing.T) {
+	artist := "Daft Punk"
+	track := &model.Track{Artist: &artist, Path: filepath.Join(t.TempDir(), "missing.mp3")}
+	NewEnricher(discardLogger(), nil).Enrich(track)
+
+	if track.Duration != 0 {
+		t.Errorf("duration = %s, want 0", track.Duration)
+	}
+	want := model.MetadataSources{fieldArtist: model.SourceHistory, fieldTitle: model.SourceFilename}
+	if len(track.Sources) != len(want) {
+		t.Errorf("sources = %v, want %v", track.Sources, want)
+	}
+	for field, source := range want {
+		if track.Sources[field] != source {
+			t.Errorf("sources[%s] = %q, want %q", field, track.Sources[field], source)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package library
+
+import (
+	"context"
+	"crypto/sha256"
+	"djtracker/internal/utils"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var audioExtensions = map[string]bool{
+	".mp3":  true,
+	".flac": true,
+	".m4a":  true,
+	".mp4":  true,
+	".aac":  true,
+	".ogg":  true,
+	".opus": true,
+	".wav":  true,
+	".aif":  true,
+	".aiff": true,
+	".wma":  true,
+}
+
+// Entry décrit un fichier audio de la bibliothèque
+type Entry struct {
+	// ID identifiant opaque du fichier, exposé au public à la place du chemin (voir EntryID)
+	ID       string
+	Path     string
+	Artist   string
+	Title    string
+	Album    string
+	Genre    string
+	Year     int
+	BPM      float64
+	Duration time.Duration
+}
+
+// ReadEntry lit les tags et la durée d'un fichier audio
+func ReadEntry(path string) *Entry {
+	entry := &Entry{
+		ID:       EntryID(path),
+		Path:     path,
+		Duration: utils.GetTrackDuration(path),
+	}
+
+	if metadata := utils.GetTrackFileMetadata(path); metadata != nil {
+		entry.Artist = strings.TrimSpace(metadata.Artist())
+		entry.Title = strings.TrimSpace(metadata.Title())
+		entry.Album = strings.TrimSpace(metadata.Album())
+		entry.Genre = strings.TrimSpace(metadata.Genre())
+		entry.Year = metadata.Year()
+		entry.BPM = utils.GetTrackBPM(metadata)
+	}
+	return entry
+}
+
+// Library indexe les fichiers audio des dossiers sources (tracker.source.paths)
+type Library struct {
+	log   *slog.Logger
+	paths []string
+
+	mu     sync.RWMutex
+	byID   map[string]*Entry
+	byPath map[string]*Entry
+	byName map[string]*Entry
+}
+
+func New(log *slog.Logger, paths []string) *Library {
+	return &Library{
+		log:    log,
+		paths:  paths,
+		byID:   make(map[string]*Entry),
+		byPath: make(map[string]*Entry),
+		byName: make(map[string]*Entry),
+	}
+}
+
+// Index parcourt les dossiers sources et remplace l'index existant.
+// Le parcours s'arrête à l'annulation du contexte.
+func (l *Library) Index(ctx context.Context) error {
+	start := time.Now()
+	byID := make(map[string]*Entry)
+	byPath := make(map[string]*Entry)
+	byName := make(map[string]*Entry)
+
+	for _, root := range l.paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				l.log.Warn("Failed to read library path", "path", path, "err", err)
+				return nil
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if d.IsDir() || !audioExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+
+			entry := ReadEntry(path)
+			byID[entry.ID] = entry
+			byPath[normalizePath(path)] = entry
+			// Nom présent dans plusieurs dossiers : ambigu, gardé à nil pour que Lookup ne choisisse pas au hasard
+			key := fileKey(path)
+			if _, ok := byName[key]; ok {
+				byName[key] = nil
+			} else {
+				byName[key] = entry
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	l.byID = byID
+	l.byPath = byPath
+	l.byName = byName
+	l.mu.Unlock()
+
+	l.log.Info("Library indexed", "files", len(byPath), "duration", time.Since(start).String())
+	return nil
+}
+
+// Lookup cherche le fichier par chemin, puis par nom de fichier
+// pour retrouver une track dont le chemin diffère (disque externe, autre machine...).
+// Retourne nil si ce nom de fichier est présent dans plusieurs dossiers de la bibliothèque.
+func (l *Library) Lookup(path string) *Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if entry, ok := l.byPath[normalizePath(path)]; ok {
+		return entry
+	}
+	return l.byName[fileKey(path)]
+}
+
+// Find retourne le fichier portant l'identifiant donné (voir EntryID), nil s'il n'est pas indexé
+func (l *Library) Find(id string) *Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.byID[id]
+}
+
+// Search cherche les fichiers dont l'artiste, le titre, l'album ou le nom de fichier contiennent
+// un mot commençant par chacun des termes de la recherche, sans tenir compte des accents.
+// Les fichiers correspondant au plus de mots sont retournés en premier.
+func (l *Library) Search(query string, limit int) []*Entry {
+	terms := utils.Words(utils.FoldDiacritics(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	type match struct {
+		entry *Entry
+		score int
+	}
+	var matches []match
+	for _, entry := range l.byPath {
+		words := utils.Words(utils.FoldDiacritics(strings.Join([]string{
+			entry.Artist, entry.Title, entry.Album, filepath.Base(entry.Path),
+		}, " ")))
+
+		score := 0
+		for _, term := range terms {
+			matched := 0
+			for _, word := range words {
+				if strings.HasPrefix(word, term) {
+					matched++
+				}
+			}
+			if matched == 0 {
+				score = 0
+				break
+			}
+			score += matched
+		}
+		if score > 0 {
+			matches = append(matches, match{entry, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.Path < matches[j].entry.Path
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	entries := make([]*Entry, 0, len(matches))
+	for _, m := range matches {
+		entries = append(entries, m.entry)
+	}
+	return entries
+}
+
+// EntryID retourne l'identifiant du fichier : une empreinte du chemin, identique quels que soient
+// la casse et les séparateurs, qui ne révèle pas l'arborescence de la machine du DJ
+func EntryID(path string) string {
+	sum := sha256.Sum256([]byte(normalizePath(path)))
+	return hex.EncodeToString(sum[:12])
+}
+
+// SamePath indique si deux chemins désignent le même fichier, quels que soient la casse et les séparateurs
+func SamePath(a, b string) bool {
+	return normalizePath(a) == normalizePath(b)
+}
+
+// Len retourne le nombre de fichiers indexés
+func (l *Library) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.byPath)
+}
+
+func normalizePath(path string) string {
+	return strings.ToLower(filepath.Clean(strings.ReplaceAll(path, `\`, "/")))
+}
+
+// fileKey retourne le nom du fichier en minuscules, quel que soit le séparateur utilisé dans le chemin
+func fileKey(path string) string {
+	path = strings.ReplaceAll(path, `\`, "/")
+	return strings.ToLower(path[strings.LastIndex(path, "/")+1:])
+}
@@ -0,0 +1,63 @@
+package library
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		filepath.Join(dir, "house", "Aerodynamic.mp3"),
+		filepath.Join(dir, "house", "Intro.mp3"),
+		filepath.Join(dir, "techno", "intro.mp3"),
+		filepath.Join(dir, "ambient", "Intro.mp3"),
+	}
+	for _, path := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lib := New(discardLogger(), []string{dir})
+	if err := lib.Index(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"same path", files[0], files[0]},
+		{"windows path", `E:\Musique\House\AERODYNAMIC.mp3`, files[0]},
+		// Chemin indexé : retrouvé même si le nom existe dans d'autres dossiers
+		{"ambiguous name, indexed path", files[2], files[2]},
+		// Nom présent dans trois dossiers : aucun fichier choisi
+		{"ambiguous name", `E:\Musique\Intro.mp3`, ""},
+		{"unknown", filepath.Join(dir, "Genesis.mp3"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := lib.Lookup(tt.path)
+			switch {
+			case tt.want == "" && entry != nil:
+				t.Errorf("Lookup(%s) = %s, want nil", tt.path, entry.Path)
+			case tt.want != "" && (entry == nil || entry.Path != tt.want):
+				t.Errorf("Lookup(%s) = %+v, want %s", tt.path, entry, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var errNoTag = errors.New("no tag found in " + trackPrefix + " entry")
+
+// xmlEntities liste les seules entités nommées reconnues, les autres '&' sont conservés tels quels
+var xmlEntities = map[string]string{
+	"amp":  "&",
+	"lt":   "<",
+	"gt":   ">",
+	"quot": `"`,
+	"apos": "'",
+}
+
+// parseExtVdjTags découpe une ligne #EXTVDJ en couples balise/valeur.
+// Le format ressemble à du XML sans en être : VirtualDJ n'échappe pas toujours les valeurs,
+// la lecture tolère donc les '&' et '<' isolés, l'UTF-8 invalide, les caractères de contrôle
+// et les balises inconnues ou non fermées.
+func parseExtVdjTags(data string) (map[string]string, error) {
+	s := strings.TrimSpace(data)
+	s = strings.TrimPrefix(s, trackPrefix)
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+
+	tags := make(map[string]string)
+	for {
+		open := strings.IndexByte(s, '<')
+		if open < 0 {
+			break
+		}
+		s = s[open+1:]
+
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			break
+		}
+		name := s[:end]
+		if !isTagName(name) {
+			// '<' isolé ou balise fermante orpheline : on reprend la recherche juste après
+			continue
+		}
+		s = s[end+1:]
+
+		var value string
+		closing := "</" + name + ">"
+		if idx := strings.Index(s, closing); idx >= 0 {
+			value, s = s[:idx], s[idx+len(closing):]
+		} else if next := nextOpeningTag(s); next >= 0 {
+			// Balise non fermée : la valeur s'arrête à la balise suivante
+			value, s = s[:next], s[next:]
+		} else {
+			value, s = s, ""
+		}
+
+		tags[strings.ToLower(name)] = cleanTagValue(decodeEntities(value))
+	}
+
+	if len(tags) == 0 {
+		return nil, errNoTag
+	}
+	return tags, nil
+}
+
+func isTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextOpeningTag retourne la position de la prochaine balise ouvrante valide, -1 s'il n'y en a pas
+func nextOpeningTag(s string) int {
+	offset := 0
+	for {
+		open := strings.IndexByte(s[offset:], '<')
+		if open < 0 {
+			return -1
+		}
+		open += offset
+
+		end := strings.IndexByte(s[open+1:], '>')
+		if end < 0 {
+			return -1
+		}
+		if isTagName(s[open+1 : open+1+end]) {
+			return open
+		}
+		offset = open + 1
+	}
+}
+
+// decodeEntities décode les entités XML nommées et numériques terminées par ';'
+func decodeEntities(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		amp := strings.IndexByte(s, '&')
+		if amp < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:amp])
+		s = s[amp:]
+
+		semi := strings.IndexByte(s, ';')
+		if semi < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		if decoded, ok := decodeEntity(s[1:semi]); ok {
+			b.WriteString(decoded)
+			s = s[semi+1:]
+		} else {
+			b.WriteByte('&')
+			s = s[1:]
+		}
+	}
+}
+
+func decodeEntity(entity string) (string, bool) {
+	if decoded, ok := xmlEntities[entity]; ok {
+		return decoded, true
+	}
+
+	if !strings.HasPrefix(entity, "#") || len(entity) < 2 {
+		return "", false
+	}
+
+	var code int64
+	var err error
+	if entity[1] == 'x' || entity[1] == 'X' {
+		code, err = strconv.ParseInt(entity[2:], 16, 32)
+	} else {
+		code, err = strconv.ParseInt(entity[1:], 10, 32)
+	}
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return "", false
+	}
+	return string(rune(code)), true
+}
+
+// cleanTagValue remplace les caractères de contrôle par des espaces et retire les espaces superflus
+func cleanTagValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
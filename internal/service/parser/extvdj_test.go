@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestParseExtVdjTags(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want map[string]string
+	}{
+		{
+			"escaped ampersand is not double escaped",
+			"#EXTVDJ:<artist>Simon &amp; Garfunkel</artist>",
+			map[string]string{"artist": "Simon & Garfunkel"},
+		},
+		{
+			"raw ampersand",
+			"#EXTVDJ:<artist>Kavinsky & Lovefoxxx</artist>",
+			map[string]string{"artist": "Kavinsky & Lovefoxxx"},
+		},
+		{
+			"numeric entities",
+			"#EXTVDJ:<title>&#233;t&#xE9; &#invalid;</title>",
+			map[string]string{"title": "été &#invalid;"},
+		},
+		{
+			"stray lower than",
+			"#EXTVDJ:<title>Nightcall <Drive OST> < 3</title><bpm>122</bpm>",
+			map[string]string{"title": "Nightcall <Drive OST> < 3", "bpm": "122"},
+		},
+		{
+			"unclosed tag ends at next tag",
+			"#EXTVDJ:<artist>Justice<title>D.A.N.C.E.</title>",
+			map[string]string{"artist": "Justice", "title": "D.A.N.C.E."},
+		},
+		{
+			"control characters and invalid utf-8",
+			"#EXTVDJ:<title>One\tMore\x00Time \xff</title>",
+			map[string]string{"title": "One More Time \uFFFD"},
+		},
+		{
+			"tag names are lower cased",
+			"#EXTVDJ:<Artist>Stromae</Artist>",
+			map[string]string{"artist": "Stromae"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExtVdjTags(tt.line)
+			if err != nil {
+				t.Fatalf("parseExtVdjTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExtVdjTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// historyLines retourne les lignes #EXTVDJ de l'historique d'exemple (testdata/history.m3u)
+func historyLines(tb testing.TB) []string {
+	file, err := os.Open("testdata/history.m3u")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), trackPrefix) {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return lines
+}
+
+func TestParseExtVdjTagsHistory(t *testing.T) {
+	for _, line := range historyLines(t) {
+		tags, err := parseExtVdjTags(line)
+		if err != nil {
+			t.Errorf("parseExtVdjTags(%q) error = %v", line, err)
+			continue
+		}
+		if tags["lastplaytime"] == "" {
+			t.Errorf("parseExtVdjTags(%q) has no lastplaytime", line)
+		}
+	}
+}
+
+func FuzzParseExtVdjTags(f *testing.F) {
+	for _, line := range historyLines(f) {
+		f.Add(line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		tags, err := parseExtVdjTags(line)
+		if err != nil {
+			if tags != nil {
+				t.Errorf("tags = %q returned with error %v", tags, err)
+			}
+			return
+		}
+		if len(tags) == 0 {
+			t.Error("no tags returned without error")
+		}
+
+		for name, value := range tags {
+			if !isTagName(name) || name != strings.ToLower(name) {
+				t.Errorf("invalid tag name %q", name)
+			}
+			if !utf8.ValidString(value) {
+				t.Errorf("tag %s has invalid UTF-8 value %q", name, value)
+			}
+			if value != strings.TrimSpace(value) {
+				t.Errorf("tag %s value %q is not trimmed", name, value)
+			}
+			if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				t.Errorf("tag %s value %q has control characters", name, value)
+			}
+		}
+	})
+}
@@ -2,19 +2,30 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"djtracker/internal/config"
 	"djtracker/internal/model"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
 const (
 	virtualDJ = "virtualdj"
 )
 
+// State décrit la position de lecture du parser dans l'historique
+type State struct {
+	File       string
+	LastReadAt time.Time
+}
+
 type Parser interface {
 	CheckState() error
-	StartHistoryTracking(reader *bufio.Reader, ch chan *model.Track) error
+	State() State
+	// Parse convertit une entrée brute de l'historique (telle que stockée dans ParseFailure.Raw)
+	Parse(raw string) (*model.Track, error)
+	StartHistoryTracking(ctx context.Context, reader *bufio.Reader, ch chan *model.Track, failures chan *model.ParseFailure) error
 	WithHistoryTrackReader(fn func(reader *bufio.Reader) error) error
 }
 
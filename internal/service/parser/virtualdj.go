@@ -2,32 +2,78 @@ package parser
 
 import (
 	"bufio"
+	"context"
+	"djtracker/internal/metrics"
 	"djtracker/internal/model"
 	"djtracker/internal/utils"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 var trackPrefix = "#EXTVDJ:"
 
 type virtualDjTrack struct {
-	Time         string  `xml:"time"`
-	LastPlayTime int64   `xml:"lastplaytime"`
-	FileSize     int64   `xml:"filesize"`
-	Artist       string  `xml:"artist"`
-	Title        string  `xml:"title"`
-	Remix        string  `xml:"remix"`
-	SongLength   float64 `xml:"songlength"`
+	Time         string
+	LastPlayTime int64
+	FileSize     int64
+	Artist       string
+	Title        string
+	Remix        string
+	SongLength   float64
+	Bpm          float64
+	Key          string
+	Genre        string
+	Album        string
+	Year         int
+	Deck         int
 	Path         string
 }
 
+// newVirtualDjTrack construit la track à partir des balises lues.
+// Les balises inconnues sont ignorées, les valeurs numériques illisibles valent 0.
+func newVirtualDjTrack(tags map[string]string) *virtualDjTrack {
+	return &virtualDjTrack{
+		Time:         tags["time"],
+		LastPlayTime: parseIntTag(tags["lastplaytime"]),
+		FileSize:     parseIntTag(tags["filesize"]),
+		Artist:       tags["artist"],
+		Title:        tags["title"],
+		Remix:        tags["remix"],
+		SongLength:   parseFloatTag(tags["songlength"]),
+		Bpm:          parseFloatTag(tags["bpm"]),
+		Key:          tags["key"],
+		Genre:        tags["genre"],
+		Album:        tags["album"],
+		Year:         int(parseIntTag(tags["year"])),
+		Deck:         int(parseIntTag(tags["deck"])),
+	}
+}
+
+func parseIntTag(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return int64(parseFloatTag(value))
+	}
+	return n
+}
+
+func parseFloatTag(value string) float64 {
+	n, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0
+	}
+	return n
+}
+
 func (t *virtualDjTrack) mapToTrack() *model.Track {
 	return &model.Track{
 		Artist:   utils.EmptyStringNil(t.Artist),
@@ -35,6 +81,14 @@ func (t *virtualDjTrack) mapToTrack() *model.Track {
 		PlayAt:   time.Unix(t.LastPlayTime, 0),
 		Path:     t.Path,
 		Duration: time.Duration(t.SongLength * float64(time.Second)),
+		Remix:    utils.EmptyStringNil(t.Remix),
+		Album:    utils.EmptyStringNil(t.Album),
+		Genre:    utils.EmptyStringNil(t.Genre),
+		Key:      utils.EmptyStringNil(t.Key),
+		BPM:      utils.ZeroNil(t.Bpm),
+		Year:     utils.ZeroNil(t.Year),
+		Deck:     utils.ZeroNil(t.Deck),
+		FileSize: utils.ZeroNil(t.FileSize),
 	}
 }
 
@@ -42,6 +96,27 @@ type VirtualDJParser struct {
 	log     *slog.Logger
 	path    string
 	readAll bool
+
+	stateMu sync.RWMutex
+	state   State
+}
+
+func (p *VirtualDJParser) State() State {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.state
+}
+
+func (p *VirtualDJParser) setFile(path string) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.state.File = path
+}
+
+func (p *VirtualDJParser) setLastRead() {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.state.LastReadAt = time.Now()
 }
 
 func (p *VirtualDJParser) CheckState() error {
@@ -107,6 +182,7 @@ func (p *VirtualDJParser) WithHistoryTrackReader(fn func(reader *bufio.Reader) e
 	path, err := p.getHistoryTracksPath()
 	if err != nil {
 		p.readAll = true
+		p.setFile("")
 		return err
 	}
 
@@ -121,6 +197,7 @@ func (p *VirtualDJParser) WithHistoryTrackReader(fn func(reader *bufio.Reader) e
 		return err
 	}
 	p.readAll = false
+	p.setFile(path)
 
 	// Ouverture du reader
 	reader := bufio.NewReader(file)
@@ -129,64 +206,100 @@ func (p *VirtualDJParser) WithHistoryTrackReader(fn func(reader *bufio.Reader) e
 }
 
 // StartHistoryTracking lit le fichier d'historique et convertit les informations dans un format normalisé au programme.
-func (p *VirtualDJParser) StartHistoryTracking(reader *bufio.Reader, ch chan *model.Track) error {
+// Chaque entrée VirtualDJ tient sur deux lignes (#EXTVDJ puis chemin du fichier) : les lignes incomplètes
+// et les entrées dont le chemin n'est pas encore écrit sont gardées en attente jusqu'à la suite de l'écriture.
+// Les entrées illisibles sont envoyées dans failures.
+// La lecture s'arrête à l'annulation du contexte.
+func (p *VirtualDJParser) StartHistoryTracking(ctx context.Context, reader *bufio.Reader, ch chan *model.Track, failures chan *model.ParseFailure) error {
+	var partial strings.Builder
+	header := ""
+
 	for {
-		data, err := reader.ReadString('\n')
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		chunk, err := reader.ReadString('\n')
+		partial.WriteString(chunk)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				time.Sleep(200 * time.Millisecond)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(200 * time.Millisecond):
+				}
 				continue
 			}
-			p.log.Error("Error while reading file", err)
+			p.log.Error("Error while reading file", "err", err)
+			metrics.ParseErrors.WithLabelValues(model.FailureRead).Inc()
 			return err
 		}
 
-		if !strings.HasPrefix(data, trackPrefix) {
-			p.log.Error("No prefix detected")
+		line := partial.String()
+		partial.Reset()
+		p.setLastRead()
+
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
-		path, err := reader.ReadString('\n')
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				p.log.Error("Reached end of file on second line")
-				continue
+		if strings.HasPrefix(line, trackPrefix) {
+			if header != "" {
+				p.log.Error("No path found for history entry")
+				p.reportFailure(failures, model.FailureMissingPath, header, errors.New("entry is not followed by a file path"))
 			}
-			p.log.Error("Error while reading file", err)
-			return err
+			header = line
+			continue
 		}
 
-		track, err := p.parseStringTrackData(data, path)
+		if header == "" {
+			p.log.Error("No prefix detected")
+			p.reportFailure(failures, model.FailureMissingPrefix, line, errors.New("line is not preceded by an "+trackPrefix+" entry"))
+			continue
+		}
+
+		raw := strings.TrimSpace(header) + "\n" + strings.TrimSpace(line)
+		header = ""
+
+		track, err := p.Parse(raw)
 		if err != nil {
 			p.log.Error("Error on parse track data", "err", err)
+			p.reportFailure(failures, model.FailureInvalidData, raw, err)
 			continue
 		}
+		metrics.TracksParsed.Inc()
 		ch <- track
 	}
 }
 
-func (p *VirtualDJParser) parseStringTrackData(data, path string) (*model.Track, error) {
-	reformattedData := sanitizeXML(data)
-	trackData, err := unmarshalXML(reformattedData)
-	if err != nil {
-		return nil, err
+func (p *VirtualDJParser) reportFailure(failures chan *model.ParseFailure, kind, raw string, err error) {
+	metrics.ParseErrors.WithLabelValues(kind).Inc()
+	failures <- &model.ParseFailure{
+		Source:    virtualDJ,
+		File:      p.State().File,
+		Kind:      kind,
+		Raw:       strings.TrimSpace(raw),
+		Error:     err.Error(),
+		CreatedAt: time.Now(),
 	}
-
-	trackData.Path = strings.TrimSpace(path)
-	return trackData.mapToTrack(), nil
 }
 
-func sanitizeXML(data string) string {
-	data = strings.TrimSpace(data)
-	data = strings.Replace(data, trackPrefix, "", 1)
-	data = strings.ReplaceAll(data, "&", "&amp;")
-	return "<track>" + data + "</track>"
+// Parse convertit une entrée composée de la ligne #EXTVDJ et de la ligne du chemin, séparées par un retour à la ligne
+func (p *VirtualDJParser) Parse(raw string) (*model.Track, error) {
+	data, path, found := strings.Cut(raw, "\n")
+	if !found || strings.TrimSpace(path) == "" {
+		return nil, errors.New("missing file path in history entry")
+	}
+	return p.parseStringTrackData(data, path)
 }
 
-func unmarshalXML(data string) (*virtualDjTrack, error) {
-	var t virtualDjTrack
-	if err := xml.Unmarshal([]byte(data), &t); err != nil {
+func (p *VirtualDJParser) parseStringTrackData(data, path string) (*model.Track, error) {
+	tags, err := parseExtVdjTags(data)
+	if err != nil {
 		return nil, err
 	}
-	return &t, nil
+
+	trackData := newVirtualDjTrack(tags)
+	trackData.Path = strings.TrimSpace(path)
+	return trackData.mapToTrack(), nil
 }
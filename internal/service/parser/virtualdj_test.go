@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"djtracker/internal/model"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestParser() *VirtualDJParser {
+	return &VirtualDJParser{log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+const (
+	testPlayTime = 1760900000
+	testPath     = `C:\Music\Daft Punk - One More Time.mp3`
+)
+
+// baseTrack track attendue pour une entrée ne contenant que les balises minimales
+func baseTrack() model.Track {
+	return model.Track{
+		Artist:   ptr("Daft Punk"),
+		Name:     "One More Time",
+		PlayAt:   time.Unix(testPlayTime, 0),
+		Duration: 320500 * time.Millisecond,
+		Path:     testPath,
+	}
+}
+
+func TestVirtualDJParse(t *testing.T) {
+	const base = "<lastplaytime>1760900000</lastplaytime><artist>Daft Punk</artist><title>One More Time</title><songlength>320.5</songlength>"
+
+	tests := []struct {
+		name   string
+		extra  string
+		expect func(track *model.Track)
+	}{
+		{"minimal entry", "", func(*model.Track) {}},
+		{"remix", "<remix>Radio Edit</remix>", func(t *model.Track) { t.Remix = ptr("Radio Edit") }},
+		{"empty remix", "<remix></remix>", func(*model.Track) {}},
+		{"bpm", "<bpm>122.96</bpm>", func(t *model.Track) { t.BPM = ptr(122.96) }},
+		{"bpm with comma", "<bpm>122,5</bpm>", func(t *model.Track) { t.BPM = ptr(122.5) }},
+		{"invalid bpm", "<bpm>fast</bpm>", func(*model.Track) {}},
+		{"key", "<key>F#m</key>", func(t *model.Track) { t.Key = ptr("F#m") }},
+		{"genre", "<genre>French Touch</genre>", func(t *model.Track) { t.Genre = ptr("French Touch") }},
+		{"album", "<album>Discovery</album>", func(t *model.Track) { t.Album = ptr("Discovery") }},
+		{"year", "<year>2001</year>", func(t *model.Track) { t.Year = ptr(2001) }},
+		{"deck", "<deck>2</deck>", func(t *model.Track) { t.Deck = ptr(2) }},
+		{"file size", "<filesize>7654321</filesize>", func(t *model.Track) { t.FileSize = ptr(int64(7654321)) }},
+		{
+			"all fields",
+			"<filesize>7654321</filesize><remix>Radio Edit</remix><bpm>122.96</bpm><key>F#m</key><genre>French Touch</genre><album>Discovery</album><year>2001</year><deck>1</deck>",
+			func(t *model.Track) {
+				t.FileSize = ptr(int64(7654321))
+				t.Remix = ptr("Radio Edit")
+				t.BPM = ptr(122.96)
+				t.Key = ptr("F#m")
+				t.Genre = ptr("French Touch")
+				t.Album = ptr("Discovery")
+				t.Year = ptr(2001)
+				t.Deck = ptr(1)
+			},
+		},
+		{"unknown tag", "<comment>played twice</comment>", func(*model.Track) {}},
+	}
+
+	parser := newTestParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.Parse(trackPrefix + base + tt.extra + "\n" + testPath)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			want := baseTrack()
+			tt.expect(&want)
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestVirtualDJParseWithoutOptionalHeaderFields(t *testing.T) {
+	got, err := newTestParser().Parse(trackPrefix + "<lastplaytime>1760900000</lastplaytime><title>Intro</title>\n" + testPath)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := model.Track{Name: "Intro", PlayAt: time.Unix(testPlayTime, 0), Path: testPath}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("Parse() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestVirtualDJParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"missing path", trackPrefix + "<title>One More Time</title>"},
+		{"blank path", trackPrefix + "<title>One More Time</title>\n   "},
+		{"no tag", trackPrefix + "one more time\n" + testPath},
+	}
+
+	parser := newTestParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if track, err := parser.Parse(tt.raw); err == nil {
+				t.Errorf("Parse() = %+v, want error", track)
+			}
+		})
+	}
+}
+
+// VirtualDJ écrit l'historique par morceaux : une ligne coupée entre deux écritures
+// n'est lue qu'une fois complète, et l'entrée n'est envoyée qu'avec son chemin
+func TestVirtualDJStartHistoryTrackingPartialWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2025-10-19.m3u")
+	w, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	r, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tracks := make(chan *model.Track, 4)
+	failures := make(chan *model.ParseFailure, 4)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- newTestParser().StartHistoryTracking(ctx, bufio.NewReader(r), tracks, failures)
+	}()
+
+	writes := []string{
+		trackPrefix + "<lastplaytime>1760900000</lastplaytime><artist>Daft",
+		" Punk</artist><title>One More Time</title><songlength>320.5</songlength>\r",
+		"\n" + `C:\Music\Daft Punk - `,
+		"One More Time.mp3",
+	}
+	for _, chunk := range writes {
+		if _, err := w.WriteString(chunk); err != nil {
+			t.Fatal(err)
+		}
+		// Attente d'au moins une lecture en fin de fichier
+		select {
+		case track := <-tracks:
+			t.Fatalf("track %+v sent after incomplete write %q", track, chunk)
+		case failure := <-failures:
+			t.Fatalf("failure %+v reported after incomplete write %q", failure, chunk)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+
+	if _, err := w.WriteString("\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case track := <-tracks:
+		if want := baseTrack(); !reflect.DeepEqual(*track, want) {
+			t.Errorf("track =\n%+v\nwant\n%+v", *track, want)
+		}
+	case failure := <-failures:
+		t.Fatalf("failure %+v reported for a complete entry", failure)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no track sent once the entry is complete")
+	}
+
+	cancel()
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("StartHistoryTracking() = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StartHistoryTracking() did not stop on cancel")
+	}
+	if len(tracks) > 0 || len(failures) > 0 {
+		t.Errorf("%d tracks and %d failures sent more than once", len(tracks), len(failures))
+	}
+}
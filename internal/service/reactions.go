@@ -0,0 +1,55 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// Reactions enregistre les likes du public sur la track en cours et diffuse les compteurs
+type Reactions struct {
+	log         *slog.Logger
+	store       repository.LikeStore
+	broadcaster *Broadcaster[*model.Reactions]
+}
+
+func NewReactions(log *slog.Logger, store repository.LikeStore) *Reactions {
+	return &Reactions{
+		log:         log,
+		store:       store,
+		broadcaster: NewBroadcaster[*model.Reactions](log),
+	}
+}
+
+// Subscribe Créer un nouveau channel abonné aux compteurs de réactions mis à jour
+func (r *Reactions) Subscribe() (chan *model.Reactions, func()) {
+	return r.broadcaster.Subscribe(1)
+}
+
+// Like enregistre le like du navigateur identifié par token. Le nouveau compteur n'est
+// diffusé que si le navigateur n'avait pas encore aimé la track, sans attendre les clients lents.
+func (r *Reactions) Like(track *model.Track, token string) (*model.Reactions, bool, error) {
+	added, err := r.store.AddLike(track.ID, token, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+
+	reactions, err := r.Count(track)
+	if err != nil {
+		return nil, false, err
+	}
+	if added {
+		r.broadcaster.BroadcastLatest(reactions)
+	}
+	return reactions, added, nil
+}
+
+// Count retourne les réactions reçues par la track
+func (r *Reactions) Count(track *model.Track) (*model.Reactions, error) {
+	likes, err := r.store.CountLikes(track.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Reactions{TrackID: track.ID, Likes: likes}, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Un client SSE qui ne lit plus son channel ne doit bloquer ni les likes ni son désabonnement
+func TestReactionsLikeDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	reactions := NewReactions(discardLogger(), repository.NewMemory())
+	track := &model.Track{ID: 1}
+
+	slow, unsubscribe := reactions.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, token := range []string{"a", "b", "c"} {
+			if _, _, err := reactions.Like(track, token); err != nil {
+				t.Errorf("Like(%s): %v", token, err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Like blocked on a subscriber with a full channel")
+	}
+
+	// Seul le dernier compteur reste en attente
+	if got := <-slow; got.Likes != 3 {
+		t.Errorf("pending reactions = %d likes, want 3", got.Likes)
+	}
+
+	unsubscribed := make(chan struct{})
+	go func() {
+		unsubscribe()
+		close(unsubscribed)
+	}()
+	select {
+	case <-unsubscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unsubscribe deadlocked")
+	}
+}
+
+func TestReactionsLikeIsDeduplicatedPerToken(t *testing.T) {
+	reactions := NewReactions(discardLogger(), repository.NewMemory())
+	track := &model.Track{ID: 7}
+
+	updates, unsubscribe := reactions.Subscribe()
+	defer unsubscribe()
+
+	first, added, err := reactions.Like(track, "token")
+	if err != nil || !added || first.Likes != 1 {
+		t.Fatalf("first like = %+v, %v, %v", first, added, err)
+	}
+	<-updates
+
+	second, added, err := reactions.Like(track, "token")
+	if err != nil || added || second.Likes != 1 {
+		t.Fatalf("second like = %+v, %v, %v", second, added, err)
+	}
+	select {
+	case update := <-updates:
+		t.Errorf("duplicate like broadcast %+v", update)
+	default:
+	}
+}
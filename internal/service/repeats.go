@@ -0,0 +1,64 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"strings"
+)
+
+// FindPreviousPlays Retourne les lectures de l'événement en cours correspondant à la track,
+// par chemin de fichier ou par artiste et titre normalisés, de la plus récente à la plus ancienne.
+// Les tracks passées (voir model.Track.Skipped) ne comptent pas comme des lectures.
+func (t *Tracker) FindPreviousPlays(track *model.Track) ([]*model.RepeatWarning, error) {
+	event := t.repo.CurrentEvent()
+	if event == nil {
+		return nil, nil
+	}
+
+	identity := ""
+	if track.Name != "" {
+		identity = track.Identity()
+	}
+
+	// Seules les lectures du même fichier ou du même morceau sont chargées
+	plays, err := t.repo.FindEventPlays(event.ID, track.Path, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []*model.RepeatWarning
+	for _, previous := range plays {
+		if previous.ID == track.ID {
+			continue
+		}
+
+		matchedBy := model.MatchedByIdentity
+		if track.Path != "" && strings.EqualFold(previous.Path, track.Path) {
+			matchedBy = model.MatchedByPath
+		}
+
+		warnings = append(warnings, &model.RepeatWarning{
+			Track:     track,
+			Previous:  previous,
+			MatchedBy: matchedBy,
+		})
+	}
+	return warnings, nil
+}
+
+// checkRepeat Marque la track comme répétée si elle a déjà été jouée pendant l'événement
+func (t *Tracker) checkRepeat(track *model.Track) *model.RepeatWarning {
+	warnings, err := t.FindPreviousPlays(track)
+	if err != nil {
+		t.log.Error("Failed to check previous plays", "err", err)
+		return nil
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	warning := warnings[0]
+	track.RepeatOf = &warning.Previous.ID
+	t.log.Warn("Track already played during this event",
+		"track", track.Name, "previous", warning.Previous.ID, "matched_by", warning.MatchedBy)
+	return warning
+}
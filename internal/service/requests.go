@@ -0,0 +1,156 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service/library"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	maxGuestLength   = 64
+	maxMessageLength = 255
+)
+
+var (
+	// ErrUnknownFile est retournée pour une demande d'un fichier absent de la bibliothèque
+	ErrUnknownFile = errors.New("file not found in library")
+	// ErrRequestClosed est retournée pour une décision sur une demande déjà jouée ou refusée
+	ErrRequestClosed = errors.New("request is already closed")
+)
+
+// Requests gère les demandes de morceaux du public : recherche dans la bibliothèque,
+// file des demandes de l'événement en cours et décisions du DJ
+type Requests struct {
+	log     *slog.Logger
+	store   repository.Store
+	library *library.Library
+}
+
+func NewRequests(log *slog.Logger, store repository.Store, library *library.Library) *Requests {
+	return &Requests{
+		log:     log,
+		store:   store,
+		library: library,
+	}
+}
+
+// Search cherche les morceaux pouvant être demandés
+func (r *Requests) Search(query string, limit int) []*library.Entry {
+	return r.library.Search(query, limit)
+}
+
+// Submit enregistre la demande d'un fichier de la bibliothèque désigné par son identifiant
+// (voir library.EntryID). Une demande encore ouverte pour le même fichier est retournée
+// telle quelle plutôt que dupliquée.
+func (r *Requests) Submit(id, guest, message string) (*model.SongRequest, error) {
+	entry := r.library.Find(id)
+	if entry == nil {
+		return nil, ErrUnknownFile
+	}
+
+	queue, err := r.Queue()
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range queue {
+		if request.IsOpen() && library.SamePath(request.Path, entry.Path) {
+			return request, nil
+		}
+	}
+
+	request := &model.SongRequest{
+		Path:      entry.Path,
+		Artist:    optional(entry.Artist),
+		Title:     entry.Title,
+		Guest:     optional(truncateText(guest, maxGuestLength)),
+		Message:   optional(truncateText(message, maxMessageLength)),
+		Status:    model.RequestPending,
+		CreatedAt: time.Now(),
+	}
+	if request.Title == "" {
+		request.Title = strings.TrimSuffix(filepath.Base(entry.Path), filepath.Ext(entry.Path))
+	}
+
+	if err := r.store.AddSongRequest(request); err != nil {
+		return nil, err
+	}
+	r.log.Info("Song requested", "request", request.ID, "path", request.Path)
+	return request, nil
+}
+
+// Queue retourne les demandes de l'événement en cours, de la plus ancienne à la plus récente
+func (r *Requests) Queue() ([]*model.SongRequest, error) {
+	event := r.store.CurrentEvent()
+	if event == nil {
+		return nil, repository.ErrNoEvent
+	}
+	return r.store.FindSongRequests(event.ID)
+}
+
+// Decide accepte ou refuse une demande encore ouverte. Retourne nil si elle n'existe pas.
+func (r *Requests) Decide(id int64, accept bool) (*model.SongRequest, error) {
+	request, err := r.store.FindSongRequest(id)
+	if err != nil || request == nil {
+		return nil, err
+	}
+	if !request.IsOpen() {
+		return request, ErrRequestClosed
+	}
+
+	now := time.Now()
+	request.Status, request.UpdatedAt = model.RequestRejected, &now
+	if accept {
+		request.Status = model.RequestAccepted
+	}
+	if err := r.store.UpdateSongRequest(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// markRequestsPlayed Marque comme jouées les demandes ouvertes de l'événement pour le fichier de la track
+func (t *Tracker) markRequestsPlayed(track *model.Track) {
+	if track.ID == 0 || track.Path == "" {
+		return
+	}
+
+	requests, err := t.repo.FindSongRequests(track.EventID)
+	if err != nil {
+		t.log.Error("Failed to load song requests", "err", err)
+		return
+	}
+
+	for _, request := range requests {
+		if !request.IsOpen() || !library.SamePath(request.Path, track.Path) {
+			continue
+		}
+		request.Status, request.TrackID, request.UpdatedAt = model.RequestPlayed, &track.ID, &track.PlayAt
+		if err := t.repo.UpdateSongRequest(request); err != nil {
+			t.log.Error("Failed to mark song request as played", "err", err, "request", request.ID)
+			continue
+		}
+		t.log.Info("Requested song played", "request", request.ID, "track", track.ID)
+	}
+}
+
+// optional retourne nil pour un texte vide
+func optional(value string) *string {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	return &value
+}
+
+// truncateText coupe le texte à max caractères
+func truncateText(value string, max int) string {
+	runes := []rune(strings.TrimSpace(value))
+	if len(runes) > max {
+		return string(runes[:max])
+	}
+	return string(runes)
+}
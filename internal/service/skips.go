@@ -0,0 +1,33 @@
+package service
+
+import (
+	"djtracker/internal/model"
+	"time"
+)
+
+// checkSkipped Marque la track précédente comme passée si la suivante arrive avant la durée
+// minimale de lecture (tracker.min_play_time). Retourne la track corrigée, nil si elle a été jouée.
+func (t *Tracker) checkSkipped(next *model.Track) *model.Track {
+	minPlayTime := time.Duration(t.config.Tracker.MinPlayTime) * time.Second
+	previous := t.LastTrack()
+	if minPlayTime <= 0 || previous == nil || previous.Skipped || previous.ID == 0 {
+		return nil
+	}
+
+	played := next.PlayAt.Sub(previous.PlayAt)
+	if played < 0 || played >= minPlayTime {
+		return nil
+	}
+
+	if err := t.repo.MarkTrackSkipped(previous.ID, time.Now()); err != nil {
+		t.log.Error("Failed to mark track as skipped", "err", err, "track", previous.ID)
+		return nil
+	}
+
+	// Copie : la track précédente peut encore être en cours d'envoi aux abonnés
+	skipped := *previous
+	skipped.Skipped = true
+	t.log.Info("Track skipped before minimum play time",
+		"track", skipped.Name, "played", played, "min_play_time", minPlayTime)
+	return &skipped
+}
@@ -2,35 +2,68 @@ package service
 
 import (
 	"bufio"
+	"context"
 	"djtracker/internal/config"
+	"djtracker/internal/metrics"
 	"djtracker/internal/model"
 	"djtracker/internal/repository"
 	"djtracker/internal/service/parser"
 	"log/slog"
+	"sync"
 	"time"
 )
 
 type Tracker struct {
 	log    *slog.Logger
 	config *config.Config
-	repo   *repository.Repository
+	repo   repository.Store
+
+	enricher *Enricher
 
 	parser        parser.Parser
 	liveTrackList chan *model.Track
+	parseFailures chan *model.ParseFailure
+
+	// current est la track en cours de lecture, source de vérité pour les lectures
+	currentMu sync.RWMutex
+	current   *model.Track
+
+	trackBroadcaster      *Broadcaster[*model.Track]
+	warningBroadcaster    *Broadcaster[*model.RepeatWarning]
+	correctionBroadcaster *Broadcaster[*model.Track]
+
+	statusMu sync.RWMutex
+	status   IngestionStatus
+
+	done chan struct{}
+}
 
-	trackBroadcaster *Broadcaster[*model.Track]
+// IngestionStatus décrit l'état de la lecture de l'historique et de l'enregistrement des tracks
+type IngestionStatus struct {
+	ReaderRunning bool
+	Restarts      int
+	LastError     error
+	LastErrorAt   time.Time
+	PersistError  error
 }
 
-func NewTracker(log *slog.Logger, config *config.Config, repo *repository.Repository, parser parser.Parser) *Tracker {
+func NewTracker(log *slog.Logger, config *config.Config, repo repository.Store, parser parser.Parser, enricher *Enricher) *Tracker {
 	return &Tracker{
 		log:    log,
 		config: config,
 		repo:   repo,
 
+		enricher: enricher,
+
 		parser:        parser,
 		liveTrackList: make(chan *model.Track, 1),
+		parseFailures: make(chan *model.ParseFailure, 1),
+
+		trackBroadcaster:      NewBroadcaster[*model.Track](log),
+		warningBroadcaster:    NewBroadcaster[*model.RepeatWarning](log),
+		correctionBroadcaster: NewBroadcaster[*model.Track](log),
 
-		trackBroadcaster: NewBroadcaster[*model.Track](log),
+		done: make(chan struct{}),
 	}
 }
 
@@ -39,13 +72,51 @@ func (t *Tracker) SubscribeForTracks() (chan *model.Track, func()) {
 	return t.trackBroadcaster.Subscribe(1)
 }
 
-// GetCurrentTrack Récupère la track actuelle et l'envoie dans le channel
+// Enrich Complète les métadonnées manquantes de la track (voir Enricher)
+func (t *Tracker) Enrich(track *model.Track) {
+	t.enricher.Enrich(track)
+}
+
+// SubscribeForWarnings Créer un nouveau channel abonné aux avertissements destinés au DJ
+func (t *Tracker) SubscribeForWarnings() (chan *model.RepeatWarning, func()) {
+	return t.warningBroadcaster.Subscribe(1)
+}
+
+// SubscribeForCorrections Créer un nouveau channel abonné aux tracks déjà diffusées puis marquées comme passées
+func (t *Tracker) SubscribeForCorrections() (chan *model.Track, func()) {
+	return t.correctionBroadcaster.Subscribe(1)
+}
+
+// Status Retourne l'état de l'ingestion et la position du parser
+func (t *Tracker) Status() (IngestionStatus, parser.State) {
+	t.statusMu.RLock()
+	defer t.statusMu.RUnlock()
+	return t.status, t.parser.State()
+}
+
+// CheckDatabase Vérifie que la base de données est joignable
+func (t *Tracker) CheckDatabase(ctx context.Context) error {
+	return t.repo.Ping(ctx)
+}
+
+// LastTrack Retourne la dernière track reçue, même terminée
+func (t *Tracker) LastTrack() *model.Track {
+	t.currentMu.RLock()
+	defer t.currentMu.RUnlock()
+	return t.current
+}
+
+func (t *Tracker) updateStatus(update func(status *IngestionStatus)) {
+	t.statusMu.Lock()
+	defer t.statusMu.Unlock()
+	update(&t.status)
+}
+
+// GetCurrentTrack Récupère la track actuelle depuis l'état en mémoire
 func (t *Tracker) GetCurrentTrack() *model.Track {
-	track, err := t.repo.FindLastTrack()
-	if err != nil {
-		t.log.Error("Failed to retrieve current track", err)
-		return nil
-	}
+	t.currentMu.RLock()
+	track := t.current
+	t.currentMu.RUnlock()
 
 	if track == nil {
 		t.log.Debug("No current track was found")
@@ -60,30 +131,121 @@ func (t *Tracker) GetCurrentTrack() *model.Track {
 	return track
 }
 
-func (t *Tracker) StartTracking() {
-	go t.superviseHistoryReader()
+func (t *Tracker) setCurrentTrack(track *model.Track) {
+	t.currentMu.Lock()
+	defer t.currentMu.Unlock()
+	t.current = track
+}
+
+// loadCurrentTrack Initialise l'état en mémoire avec la dernière track enregistrée en base
+func (t *Tracker) loadCurrentTrack() {
+	track, err := t.repo.FindLastTrack()
+	if err != nil {
+		t.log.Error("Failed to retrieve current track", "err", err)
+		return
+	}
+	t.setCurrentTrack(track)
+}
+
+// StartTracking Démarre la lecture de l'historique jusqu'à l'annulation du contexte.
+// Les tracks déjà lues sont enregistrées avant la fermeture des abonnés (voir Wait).
+func (t *Tracker) StartTracking(ctx context.Context) {
+	t.loadCurrentTrack()
+	go t.superviseHistoryReader(ctx)
 	go t.listenHistory()
 }
 
-func (t *Tracker) superviseHistoryReader() {
+// Wait Bloque jusqu'à ce que toutes les tracks reçues soient enregistrées et les abonnés fermés
+func (t *Tracker) Wait() {
+	<-t.done
+}
+
+func (t *Tracker) superviseHistoryReader(ctx context.Context) {
+	defer close(t.liveTrackList)
+	defer close(t.parseFailures)
+
 	for {
 		err := t.parser.WithHistoryTrackReader(func(reader *bufio.Reader) error {
 			t.log.Info("Ready to read tracks history")
-			return t.parser.StartHistoryTracking(reader, t.liveTrackList)
+			t.updateStatus(func(status *IngestionStatus) { status.ReaderRunning = true })
+			defer t.updateStatus(func(status *IngestionStatus) { status.ReaderRunning = false })
+			return t.parser.StartHistoryTracking(ctx, reader, t.liveTrackList, t.parseFailures)
 		})
 
+		if ctx.Err() != nil {
+			t.log.Info("History reader stopped")
+			return
+		}
+
 		if err != nil {
 			t.log.Error("history reader crashed", "err", err)
-			time.Sleep(2 * time.Second)
+			metrics.ParserRestarts.Inc()
+			t.updateStatus(func(status *IngestionStatus) {
+				status.Restarts++
+				status.LastError = err
+				status.LastErrorAt = time.Now()
+			})
+			select {
+			case <-ctx.Done():
+				t.log.Info("History reader stopped")
+				return
+			case <-time.After(2 * time.Second):
+			}
 		}
 	}
 }
 
 // listenHistory Reçoit les Tracks traités par le Parser
-// et les envoie dans les différents canaux de diffusion
+// et les envoie dans les différents canaux de diffusion.
+// Les entrées illisibles sont mises en quarantaine en base.
 func (t *Tracker) listenHistory() {
-	for track := range t.liveTrackList {
-		t.repo.AddTrackToHistory(track)
-		t.trackBroadcaster.Broadcast(track)
+	defer close(t.done)
+	defer t.trackBroadcaster.Close()
+	defer t.warningBroadcaster.Close()
+	defer t.correctionBroadcaster.Close()
+
+	tracks, failures := t.liveTrackList, t.parseFailures
+	for tracks != nil || failures != nil {
+		select {
+		case track, ok := <-tracks:
+			if !ok {
+				tracks = nil
+				continue
+			}
+			t.handleTrack(track)
+		case failure, ok := <-failures:
+			if !ok {
+				failures = nil
+				continue
+			}
+			if err := t.repo.AddParseFailure(failure); err != nil {
+				t.log.Error("Failed to save parse failure", "err", err, "raw", failure.Raw)
+			}
+		}
+	}
+}
+
+func (t *Tracker) handleTrack(track *model.Track) {
+	t.enricher.Enrich(track)
+	skipped := t.checkSkipped(track)
+	warning := t.checkRepeat(track)
+
+	err := t.repo.AddTrackToHistory(track)
+	if err != nil {
+		t.log.Error("Failed to save track", "err", err)
+		metrics.TracksFailed.Inc()
+	} else {
+		metrics.TracksPersisted.Inc()
+		t.markRequestsPlayed(track)
+	}
+	t.updateStatus(func(status *IngestionStatus) { status.PersistError = err })
+
+	t.setCurrentTrack(track)
+	if skipped != nil {
+		t.correctionBroadcaster.Broadcast(skipped)
+	}
+	t.trackBroadcaster.Broadcast(track)
+	if warning != nil {
+		t.warningBroadcaster.Broadcast(warning)
 	}
 }
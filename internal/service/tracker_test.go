@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"djtracker/internal/config"
+	"djtracker/internal/database"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"djtracker/internal/service/parser"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func playingTrack() *model.Track {
+	artist := "Daft Punk"
+	return &model.Track{
+		Artist:   &artist,
+		Name:     "One More Time",
+		PlayAt:   time.Now(),
+		Duration: 5 * time.Minute,
+		Path:     "/music/one-more-time.mp3",
+	}
+}
+
+// BenchmarkGetCurrentTrack lecture de la track en cours depuis l'état en mémoire du tracker,
+// faite par chaque connexion SSE et chaque requête /cover/
+func BenchmarkGetCurrentTrack(b *testing.B) {
+	tracker := NewTracker(discardLogger(), &config.Config{}, repository.NewMemory(), nil, nil)
+	tracker.setCurrentTrack(playingTrack())
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if tracker.GetCurrentTrack() == nil {
+				b.Fatal("no current track")
+			}
+		}
+	})
+}
+
+// BenchmarkFindLastTrackSQLite lecture de la track en cours en base, comme avant l'état en mémoire
+func BenchmarkFindLastTrackSQLite(b *testing.B) {
+	conf := &config.Config{}
+	conf.Database.Driver = database.SQLite
+	conf.Database.Path = filepath.Join(b.TempDir(), "bench.db")
+
+	err := database.UseDb(conf, func(db *sql.DB) error {
+		repo := repository.New(discardLogger(), db, database.SQLite)
+		if err := repo.PrepareEvent(); err != nil {
+			return err
+		}
+		if err := repo.AddTrackToHistory(playingTrack()); err != nil {
+			return err
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				track, err := repo.FindLastTrack()
+				if err != nil || track == nil {
+					b.Fatalf("FindLastTrack() = %v, %v", track, err)
+				}
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+// fakeParser envoie une liste de tracks et d'entrées illisibles puis attend l'annulation du contexte
+type fakeParser struct {
+	tracks   []*model.Track
+	failures []*model.ParseFailure
+}
+
+func (p *fakeParser) CheckState() error   { return nil }
+func (p *fakeParser) State() parser.State { return parser.State{} }
+
+func (p *fakeParser) Parse(string) (*model.Track, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeParser) WithHistoryTrackReader(fn func(reader *bufio.Reader) error) error {
+	return fn(nil)
+}
+
+func (p *fakeParser) StartHistoryTracking(ctx context.Context, _ *bufio.Reader, ch chan *model.Track, failures chan *model.ParseFailure) error {
+	for _, failure := range p.failures {
+		failures <- failure
+	}
+	for _, track := range p.tracks {
+		ch <- track
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func newTestTracker(repo repository.Store, p parser.Parser) *Tracker {
+	conf := &config.Config{}
+	conf.Tracker.MinPlayTime = 30
+	return NewTracker(discardLogger(), conf, repo, p, NewEnricher(discardLogger(), nil))
+}
+
+func receive[T any](t *testing.T, ch chan T, what string) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("%s channel closed", what)
+		}
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no %s received", what)
+	}
+	var zero T
+	return zero
+}
+
+// Parcours complet sur le stockage en mémoire : enregistrement, track passée, répétition,
+// demande jouée, quarantaine des entrées illisibles et fermeture des abonnés
+func TestTrackerTracking(t *testing.T) {
+	repo := repository.NewMemory()
+	if err := repo.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	request := &model.SongRequest{Path: "/music/aerodynamic.mp3", Title: "Aerodynamic", Status: model.RequestAccepted, CreatedAt: time.Now()}
+	if err := repo.AddSongRequest(request); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-6 * time.Minute)
+	first := playingTrack()
+	first.PlayAt = start
+	// Remplacée au bout de 10 secondes : passée
+	second := &model.Track{Path: "/music/Aerodynamic.mp3", PlayAt: start.Add(10 * time.Second), Duration: 4 * time.Minute}
+	// Même fichier que la précédente : répétition
+	third := &model.Track{Name: "Aerodynamic (Live)", Path: "/music/aerodynamic.mp3", PlayAt: start.Add(5 * time.Minute), Duration: 4 * time.Minute}
+	failure := &model.ParseFailure{Kind: model.FailureInvalidData, Raw: "#EXTVDJ:", CreatedAt: time.Now()}
+
+	tracker := newTestTracker(repo, &fakeParser{
+		tracks:   []*model.Track{first, second, third},
+		failures: []*model.ParseFailure{failure},
+	})
+	tracks, unsubscribeTracks := tracker.SubscribeForTracks()
+	defer unsubscribeTracks()
+	corrections, unsubscribeCorrections := tracker.SubscribeForCorrections()
+	defer unsubscribeCorrections()
+	warnings, unsubscribeWarnings := tracker.SubscribeForWarnings()
+	defer unsubscribeWarnings()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker.StartTracking(ctx)
+
+	if got := receive(t, tracks, "track"); got.Name != "One More Time" || got.ID == 0 {
+		t.Errorf("first track = %q (id %d)", got.Name, got.ID)
+	}
+
+	skipped := receive(t, corrections, "correction")
+	if skipped.ID != first.ID || !skipped.Skipped {
+		t.Errorf("correction = track %d (skipped %v), want track %d skipped", skipped.ID, skipped.Skipped, first.ID)
+	}
+	if got := receive(t, tracks, "track"); got.Name != "Aerodynamic" {
+		t.Errorf("second track name = %q, want the file name", got.Name)
+	}
+
+	if got := receive(t, tracks, "track"); got.ID != third.ID {
+		t.Errorf("third track = %d, want %d", got.ID, third.ID)
+	}
+	warning := receive(t, warnings, "warning")
+	if warning.Previous.ID != second.ID || warning.MatchedBy != model.MatchedByPath {
+		t.Errorf("warning = previous %d matched by %s, want %d by path", warning.Previous.ID, warning.MatchedBy, second.ID)
+	}
+	if third.RepeatOf == nil || *third.RepeatOf != second.ID {
+		t.Errorf("third track RepeatOf = %v, want %d", third.RepeatOf, second.ID)
+	}
+
+	if current := tracker.GetCurrentTrack(); current == nil || current.ID != third.ID {
+		t.Errorf("GetCurrentTrack() = %v, want track %d", current, third.ID)
+	}
+
+	cancel()
+	tracker.Wait()
+
+	if _, ok := <-tracks; ok {
+		t.Error("tracks channel still open after Wait")
+	}
+
+	stored, _ := repo.FindTrack(first.ID)
+	if stored == nil || !stored.Skipped {
+		t.Errorf("first track not stored as skipped: %+v", stored)
+	}
+
+	played, _ := repo.FindSongRequest(request.ID)
+	if played.Status != model.RequestPlayed || played.TrackID == nil || *played.TrackID != second.ID {
+		t.Errorf("request = %s (track %v), want played by track %d", played.Status, played.TrackID, second.ID)
+	}
+
+	quarantined, _ := repo.FindParseFailures(false)
+	if len(quarantined) != 1 || quarantined[0].Raw != failure.Raw {
+		t.Errorf("parse failures = %v, want the quarantined entry", quarantined)
+	}
+}
+
+// Au démarrage, la track en cours est reprise depuis le stockage
+func TestTrackerLoadsCurrentTrackOnStart(t *testing.T) {
+	repo := repository.NewMemory()
+	if err := repo.PrepareEvent(); err != nil {
+		t.Fatal(err)
+	}
+	last := playingTrack()
+	if err := repo.AddTrackToHistory(last); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := newTestTracker(repo, &fakeParser{})
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker.StartTracking(ctx)
+	defer tracker.Wait()
+	defer cancel()
+
+	if current := tracker.GetCurrentTrack(); current == nil || current.ID != last.ID {
+		t.Errorf("GetCurrentTrack() = %v, want track %d", current, last.ID)
+	}
+}
+
+// Une track jouée plus longtemps que la durée minimale n'est pas corrigée
+func TestTrackerCheckSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		played  time.Duration
+		skipped bool
+	}{
+		{"replaced quickly", 10 * time.Second, true},
+		{"played long enough", 30 * time.Second, false},
+		{"clock went back", -time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemory()
+			if err := repo.PrepareEvent(); err != nil {
+				t.Fatal(err)
+			}
+			tracker := newTestTracker(repo, nil)
+
+			previous := playingTrack()
+			if err := repo.AddTrackToHistory(previous); err != nil {
+				t.Fatal(err)
+			}
+			tracker.setCurrentTrack(previous)
+
+			next := playingTrack()
+			next.PlayAt = previous.PlayAt.Add(tt.played)
+			corrected := tracker.checkSkipped(next)
+			if (corrected != nil) != tt.skipped {
+				t.Fatalf("checkSkipped() = %v, want skipped %v", corrected, tt.skipped)
+			}
+			if stored, _ := repo.FindTrack(previous.ID); stored.Skipped != tt.skipped {
+				t.Errorf("stored Skipped = %v, want %v", stored.Skipped, tt.skipped)
+			}
+			if previous.Skipped {
+				t.Error("checkSkipped modified the track shared with subscribers")
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/utils"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"time"
+)
+
+const defaultCoverFile = "cover.jpg"
+
+// defaultTexts fichiers texte écrits si la configuration n'en déclare aucun
+var defaultTexts = map[string]string{
+	"nowplaying.txt": "{{if .Artist}}{{.Artist}} - {{end}}{{.Title}}",
+	"artist.txt":     "{{.Artist}}",
+	"title.txt":      "{{.Title}}",
+}
+
+type textFile struct {
+	name     string
+	template *template.Template
+}
+
+// Files réécrit des fichiers texte et la pochette de la track en cours, vidés une fois la track terminée.
+// Chaque fichier est écrit dans un fichier temporaire puis renommé : un lecteur ne voit jamais de fichier partiel.
+type Files struct {
+	log       *slog.Logger
+	directory string
+	texts     []textFile
+	cover     string
+
+	// shown identifiant de la track affichée, 0 si les fichiers sont vides
+	shown int64
+}
+
+func NewFiles(log *slog.Logger, conf config.Files) (*Files, error) {
+	if err := os.MkdirAll(conf.Directory, 0755); err != nil {
+		return nil, fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	f := &Files{
+		log:       log,
+		directory: conf.Directory,
+		cover:     conf.Cover,
+		shown:     -1,
+	}
+	if f.cover == "" {
+		f.cover = defaultCoverFile
+	}
+
+	texts := conf.Texts
+	if len(texts) == 0 {
+		texts = defaultTexts
+	}
+	for name, content := range texts {
+		tmpl, err := template.New(name).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for file %s: %w", name, err)
+		}
+		f.texts = append(f.texts, textFile{name: name, template: tmpl})
+	}
+	sort.Slice(f.texts, func(i, j int) bool {
+		return f.texts[i].name < f.texts[j].name
+	})
+
+	return f, nil
+}
+
+func (f *Files) Name() string {
+	return "files"
+}
+
+// Close vide les fichiers : aucune track n'est en cours après l'arrêt
+func (f *Files) Close() error {
+	return f.clear()
+}
+
+func (f *Files) Send(_ context.Context, event *Event) error {
+	if event.Type != EventTrack {
+		return nil
+	}
+	return f.write(event.Track)
+}
+
+// Tick écrit la track en cours si elle n'est pas encore affichée (démarrage), vide les fichiers une fois terminée
+func (f *Files) Tick(_ context.Context, current *model.Track, now time.Time) error {
+	if current == nil || current.IsFinished(now) {
+		if f.shown == 0 {
+			return nil
+		}
+		return f.clear()
+	}
+
+	if f.shown != current.ID {
+		return f.write(current)
+	}
+	return nil
+}
+
+func (f *Files) write(track *model.Track) error {
+	data := newTrackData(track)
+
+	var errs []error
+	for _, text := range f.texts {
+		var buf bytes.Buffer
+		if err := text.template.Execute(&buf, data); err != nil {
+			errs = append(errs, fmt.Errorf("error rendering %s: %w", text.name, err))
+			continue
+		}
+		errs = append(errs, f.writeFile(text.name, buf.Bytes()))
+	}
+
+	cover, err := coverJPEG(track.Path)
+	if err != nil {
+		f.log.Warn("Failed to convert cover", "path", track.Path, "err", err)
+	}
+	if cover != nil {
+		errs = append(errs, f.writeFile(f.cover, cover))
+	} else {
+		errs = append(errs, f.removeFile(f.cover))
+	}
+
+	f.shown = track.ID
+	return errors.Join(errs...)
+}
+
+func (f *Files) clear() error {
+	var errs []error
+	for _, text := range f.texts {
+		errs = append(errs, f.writeFile(text.name, nil))
+	}
+	errs = append(errs, f.removeFile(f.cover))
+
+	f.shown = 0
+	return errors.Join(errs...)
+}
+
+// writeFile remplace le fichier de façon atomique (écriture dans un fichier temporaire puis renommage)
+func (f *Files) writeFile(name string, content []byte) error {
+	path := filepath.Join(f.directory, name)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// CreateTemp crée le fichier en 0600, les fichiers doivent rester lisibles par les autres logiciels
+	err = tmp.Chmod(0644)
+	if err == nil {
+		_, err = tmp.Write(content)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+func (f *Files) removeFile(name string) error {
+	err := os.Remove(filepath.Join(f.directory, name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// coverJPEG retourne la pochette du fichier audio au format JPEG, convertie si besoin, nil s'il n'en a pas
+func coverJPEG(path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cover := utils.GetTrackCover(path)
+	if cover == nil {
+		return nil, nil
+	}
+	if cover.MIMEType == "image/jpeg" || cover.MIMEType == "image/jpg" {
+		return cover.Data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(cover.Data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
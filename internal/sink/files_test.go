@@ -0,0 +1,180 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCoverMP3 écrit un fichier contenant uniquement un tag ID3v2.3 avec une pochette JPEG
+func writeCoverMP3(t *testing.T, path string, picture []byte) {
+	t.Helper()
+	apic := append([]byte{0}, "image/jpeg\x00"...)
+	apic = append(apic, 3, 0)
+	apic = append(apic, picture...)
+
+	frame := append([]byte("APIC"), binary.BigEndian.AppendUint32(nil, uint32(len(apic)))...)
+	frame = append(frame, 0, 0)
+	frame = append(frame, apic...)
+
+	size := len(frame)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	if err := os.WriteFile(path, append(header, frame...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertFiles vérifie le contenu des fichiers du dossier, absent si la valeur vaut nil,
+// et qu'aucun fichier temporaire n'y est resté
+func assertFiles(t *testing.T, dir string, want map[string]*string) {
+	t.Helper()
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		switch {
+		case content == nil && !os.IsNotExist(err):
+			t.Errorf("%s exists (%v), want it removed", name, err)
+		case content != nil && err != nil:
+			t.Errorf("read %s: %v", name, err)
+		case content != nil && string(data) != *content:
+			t.Errorf("%s = %q, want %q", name, data, *content)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file %s left in output directory", entry.Name())
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().Perm() != 0o644 {
+			t.Errorf("%s mode = %s, want -rw-r--r--", entry.Name(), info.Mode().Perm())
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	music := t.TempDir()
+	withCover := filepath.Join(music, "one-more-time.mp3")
+	writeCoverMP3(t, withCover, []byte("\xff\xd8\xff\xe0first cover"))
+	otherCover := filepath.Join(music, "aerodynamic.mp3")
+	writeCoverMP3(t, otherCover, []byte("\xff\xd8\xff\xe0second cover"))
+
+	files, err := NewFiles(discardLogger(), config.Files{
+		Directory: filepath.Join(dir, "obs"),
+		Texts: map[string]string{
+			"nowplaying.txt":  "{{if .Artist}}{{.Artist}} - {{end}}{{.Title}}",
+			"nowplaying.json": `{"artist": "{{.Artist}}", "title": "{{.Title}}"}`,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = filepath.Join(dir, "obs")
+	ctx := context.Background()
+
+	first := testTrack()
+	first.Path = withCover
+	if err := files.Send(ctx, &Event{Type: EventTrack, Track: first}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]*string{
+		"nowplaying.txt":  ptr("Daft Punk - One More Time"),
+		"nowplaying.json": ptr(`{"artist": "Daft Punk", "title": "One More Time"}`),
+		"cover.jpg":       ptr("\xff\xd8\xff\xe0first cover"),
+	})
+
+	// Pochette d'une autre track : le fichier est remplacé
+	second := &model.Track{ID: 13, Name: "Aerodynamic", Path: otherCover, PlayAt: time.Now(), Duration: 4 * time.Minute}
+	if err := files.Send(ctx, &Event{Type: EventTrack, Track: second}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]*string{
+		"nowplaying.txt":  ptr("Aerodynamic"),
+		"nowplaying.json": ptr(`{"artist": "", "title": "Aerodynamic"}`),
+		"cover.jpg":       ptr("\xff\xd8\xff\xe0second cover"),
+	})
+
+	// Track sans pochette : l'ancienne pochette ne doit pas rester affichée
+	third := &model.Track{ID: 14, Artist: ptr("Justice"), Name: "Genesis", Path: filepath.Join(music, "missing.mp3"), PlayAt: time.Now(), Duration: 4 * time.Minute}
+	if err := files.Send(ctx, &Event{Type: EventTrack, Track: third}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]*string{
+		"nowplaying.txt":  ptr("Justice - Genesis"),
+		"nowplaying.json": ptr(`{"artist": "Justice", "title": "Genesis"}`),
+		"cover.jpg":       nil,
+	})
+
+	// Une track passée ne change pas la track affichée
+	if err := files.Send(ctx, &Event{Type: EventSkipped, Track: first}); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]*string{"nowplaying.txt": ptr("Justice - Genesis")})
+}
+
+func TestFilesTick(t *testing.T) {
+	dir := t.TempDir()
+	music := t.TempDir()
+	path := filepath.Join(music, "one-more-time.mp3")
+	writeCoverMP3(t, path, []byte("\xff\xd8\xff\xe0cover"))
+
+	files, err := NewFiles(discardLogger(), config.Files{Directory: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	track := testTrack()
+	track.Path = path
+
+	// Démarrage : la track en cours est écrite sans attendre d'événement
+	if err := files.Tick(ctx, track, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, map[string]*string{
+		"nowplaying.txt": ptr("Daft Punk - One More Time"),
+		"artist.txt":     ptr("Daft Punk"),
+		"title.txt":      ptr("One More Time"),
+		"cover.jpg":      ptr("\xff\xd8\xff\xe0cover"),
+	})
+
+	// Track terminée : fichiers vidés, pochette supprimée
+	if err := files.Tick(ctx, track, track.PlayAt.Add(track.Duration+time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	empty := map[string]*string{
+		"nowplaying.txt": ptr(""),
+		"artist.txt":     ptr(""),
+		"title.txt":      ptr(""),
+		"cover.jpg":      nil,
+	}
+	assertFiles(t, dir, empty)
+
+	// Déjà vidés : rien n'est réécrit
+	if err := os.Remove(filepath.Join(dir, "title.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := files.Tick(ctx, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "title.txt")); !os.IsNotExist(err) {
+		t.Errorf("title.txt rewritten while already cleared: %v", err)
+	}
+
+	// Arrêt : fichiers vidés même si la track n'est pas terminée
+	if err := files.Tick(ctx, track, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := files.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, empty)
+}
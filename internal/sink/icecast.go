@@ -0,0 +1,134 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"djtracker/internal/config"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Serveurs de streaming pris en charge (outputs.icecast.server)
+const (
+	serverIcecast   = "icecast"
+	serverShoutcast = "shoutcast"
+)
+
+const (
+	defaultSongTemplate = "{{if .Artist}}{{.Artist}} - {{end}}{{.Title}}"
+	defaultIcecastUser  = "admin"
+	icecastTimeout      = 10 * time.Second
+)
+
+// Icecast met à jour le titre affiché par les auditeurs du flux à chaque nouvelle track,
+// via /admin/metadata (Icecast) ou /admin.cgi (Shoutcast)
+type Icecast struct {
+	log      *slog.Logger
+	client   *http.Client
+	baseURL  string
+	server   string
+	mount    string
+	username string
+	password string
+	template *template.Template
+}
+
+func NewIcecast(log *slog.Logger, conf config.Icecast) (*Icecast, error) {
+	i := &Icecast{
+		log:      log,
+		client:   &http.Client{Timeout: icecastTimeout},
+		baseURL:  strings.TrimSuffix(conf.URL, "/"),
+		server:   conf.Server,
+		mount:    conf.Mount,
+		username: conf.Username,
+		password: conf.Password,
+	}
+	if i.server == "" {
+		i.server = serverIcecast
+	}
+	if i.server != serverIcecast && i.server != serverShoutcast {
+		return nil, fmt.Errorf("unsupported streaming server %q, expected icecast or shoutcast", i.server)
+	}
+	if i.server == serverIcecast && i.mount == "" {
+		return nil, fmt.Errorf("icecast mount is required")
+	}
+	if i.username == "" {
+		i.username = defaultIcecastUser
+	}
+
+	song := conf.Template
+	if song == "" {
+		song = defaultSongTemplate
+	}
+	tmpl, err := template.New("song").Parse(song)
+	if err != nil {
+		return nil, fmt.Errorf("invalid icecast song template: %w", err)
+	}
+	i.template = tmpl
+	return i, nil
+}
+
+func (i *Icecast) Name() string {
+	return i.server
+}
+
+func (i *Icecast) Close() error {
+	i.client.CloseIdleConnections()
+	return nil
+}
+
+func (i *Icecast) Send(ctx context.Context, event *Event) error {
+	if event.Type != EventTrack {
+		return nil
+	}
+
+	var song bytes.Buffer
+	if err := i.template.Execute(&song, newTrackData(event.Track)); err != nil {
+		return fmt.Errorf("error rendering song template: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.metadataURL(strings.TrimSpace(song.String())), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(i.username, i.password)
+	req.Header.Set("User-Agent", "trackker")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("metadata update rejected: %s", resp.Status)
+	}
+	return nil
+}
+
+// metadataURL construit l'URL de mise à jour du titre propre au serveur
+func (i *Icecast) metadataURL(song string) string {
+	query := url.Values{}
+	query.Set("mode", "updinfo")
+	query.Set("song", song)
+
+	if i.server == serverShoutcast {
+		// Shoutcast v1 attend le mot de passe en paramètre, v2 l'identifiant du flux (sid)
+		query.Set("pass", i.password)
+		if i.mount != "" {
+			query.Set("sid", strings.TrimPrefix(i.mount, "/"))
+		}
+		return i.baseURL + "/admin.cgi?" + query.Encode()
+	}
+
+	query.Set("mount", i.mount)
+	query.Set("charset", "UTF-8")
+	return i.baseURL + "/admin/metadata?" + query.Encode()
+}
@@ -0,0 +1,157 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStreamingServer reproduit les points d'administration d'Icecast et de Shoutcast
+type fakeStreamingServer struct {
+	username, password string
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (s *fakeStreamingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/admin/metadata":
+		if username, password, ok := r.BasicAuth(); !ok || username != s.username || password != s.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	case "/admin.cgi":
+		if r.URL.Query().Get("pass") != s.password {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte("<?xml version=\"1.0\"?><iceresponse><return>1</return></iceresponse>"))
+}
+
+func (s *fakeStreamingServer) lastQuery(t *testing.T) (string, url.Values) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		t.Fatal("no request received")
+	}
+	r := s.requests[len(s.requests)-1]
+	return r.URL.Path, r.URL.Query()
+}
+
+func (s *fakeStreamingServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func newFakeStreamingServer(t *testing.T) (*fakeStreamingServer, string) {
+	t.Helper()
+	fake := &fakeStreamingServer{username: "source", password: "hackme"}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server.URL
+}
+
+func TestIcecastUpdatesMetadata(t *testing.T) {
+	fake, address := newFakeStreamingServer(t)
+	icecast, err := NewIcecast(discardLogger(), config.Icecast{
+		URL: address + "/", Mount: "/live", Username: "source", Password: "hackme",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer icecast.Close()
+
+	track := testTrack()
+	track.Name = "Été & co"
+	if err := icecast.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: time.Now()}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	path, query := fake.lastQuery(t)
+	if path != "/admin/metadata" || query.Get("mode") != "updinfo" || query.Get("mount") != "/live" ||
+		query.Get("charset") != "UTF-8" || query.Get("song") != "Daft Punk - Été & co" {
+		t.Errorf("request = %s?%s", path, query.Encode())
+	}
+
+	// Sans artiste, seul le titre est envoyé ; les tracks passées sont ignorées
+	track.Artist = nil
+	if err := icecast.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, query := fake.lastQuery(t); query.Get("song") != "Été & co" {
+		t.Errorf("song without artist = %q", query.Get("song"))
+	}
+	if err := icecast.Send(context.Background(), &Event{Type: EventSkipped, Track: track, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.count(); got != 2 {
+		t.Errorf("received %d requests, want skipped events ignored", got)
+	}
+}
+
+func TestIcecastRejectedCredentials(t *testing.T) {
+	_, address := newFakeStreamingServer(t)
+	icecast, err := NewIcecast(discardLogger(), config.Icecast{URL: address, Mount: "/live", Password: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer icecast.Close()
+
+	if err := icecast.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err == nil {
+		t.Error("Send succeeded with rejected credentials")
+	}
+}
+
+func TestShoutcastUpdatesMetadata(t *testing.T) {
+	fake, address := newFakeStreamingServer(t)
+	shoutcast, err := NewIcecast(discardLogger(), config.Icecast{
+		URL: address, Server: serverShoutcast, Mount: "/1", Password: "hackme",
+		Template: "{{.Title}} ({{.Album}})",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shoutcast.Close()
+
+	if err := shoutcast.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	path, query := fake.lastQuery(t)
+	if path != "/admin.cgi" || query.Get("mode") != "updinfo" || query.Get("sid") != "1" ||
+		query.Get("pass") != "hackme" || query.Get("song") != "One More Time (Discovery)" {
+		t.Errorf("request = %s?%s", path, query.Encode())
+	}
+}
+
+func TestNewIcecastValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.Icecast
+	}{
+		{"unknown server", config.Icecast{URL: "http://localhost", Server: "radio", Mount: "/live"}},
+		{"icecast without mount", config.Icecast{URL: "http://localhost"}},
+		{"invalid template", config.Icecast{URL: "http://localhost", Mount: "/live", Template: "{{.Title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewIcecast(discardLogger(), tt.conf); err == nil {
+				t.Error("NewIcecast accepted an invalid configuration")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package sink
+
+import (
+	"context"
+	"crypto/md5"
+	"djtracker/internal/model"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultLastFMURL = "https://ws.audioscrobbler.com/2.0/"
+
+// lastFMRetryableErrors codes d'erreur Last.fm temporaires : service indisponible, erreur interne, limite de débit
+var lastFMRetryableErrors = []int{8, 11, 16, 29}
+
+type lastFMError struct {
+	Code    int    `json:"error"`
+	Message string `json:"message"`
+}
+
+// LastFM API signée de Last.fm (track.updateNowPlaying, track.scrobble) avec la clé de session de l'utilisateur
+type LastFM struct {
+	client     *http.Client
+	url        string
+	apiKey     string
+	secret     string
+	sessionKey string
+}
+
+func NewLastFM(url, apiKey, secret, sessionKey string) *LastFM {
+	if url == "" {
+		url = defaultLastFMURL
+	}
+	return &LastFM{
+		client:     &http.Client{Timeout: scrobbleTimeout},
+		url:        url,
+		apiKey:     apiKey,
+		secret:     secret,
+		sessionKey: sessionKey,
+	}
+}
+
+func (l *LastFM) name() string {
+	return "lastfm"
+}
+
+func (l *LastFM) nowPlaying(ctx context.Context, scrobble *model.Scrobble) error {
+	params := url.Values{}
+	params.Set("artist", scrobble.Artist)
+	params.Set("track", scrobble.Title)
+	if scrobble.Album != nil {
+		params.Set("album", *scrobble.Album)
+	}
+	if scrobble.Duration > 0 {
+		params.Set("duration", strconv.Itoa(int(scrobble.Duration.Seconds())))
+	}
+	return l.call(ctx, "track.updateNowPlaying", params)
+}
+
+func (l *LastFM) scrobble(ctx context.Context, scrobbles []*model.Scrobble) error {
+	params := url.Values{}
+	for i, scrobble := range scrobbles {
+		index := "[" + strconv.Itoa(i) + "]"
+		params.Set("artist"+index, scrobble.Artist)
+		params.Set("track"+index, scrobble.Title)
+		params.Set("timestamp"+index, strconv.FormatInt(scrobble.PlayedAt.Unix(), 10))
+		if scrobble.Album != nil {
+			params.Set("album"+index, *scrobble.Album)
+		}
+		if scrobble.Duration > 0 {
+			params.Set("duration"+index, strconv.Itoa(int(scrobble.Duration.Seconds())))
+		}
+	}
+	return l.call(ctx, "track.scrobble", params)
+}
+
+// call envoie une méthode signée de l'API, les erreurs Last.fm sont retournées dans le corps JSON
+func (l *LastFM) call(ctx context.Context, method string, params url.Values) error {
+	params.Set("method", method)
+	params.Set("api_key", l.apiKey)
+	params.Set("sk", l.sessionKey)
+	params.Set("api_sig", l.sign(params))
+	params.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+
+	var apiError lastFMError
+	if json.Unmarshal(body, &apiError) == nil && apiError.Code != 0 {
+		err := fmt.Errorf("last.fm error %d: %s", apiError.Code, apiError.Message)
+		if slices.Contains(lastFMRetryableErrors, apiError.Code) {
+			return err
+		}
+		return &permanentError{err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign calcule api_sig : MD5 des paramètres triés par nom, concaténés nom puis valeur, suivis du secret
+func (l *LastFM) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "format" && key != "callback" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteString(params.Get(key))
+	}
+	b.WriteString(l.secret)
+
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
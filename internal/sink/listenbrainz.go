@@ -0,0 +1,117 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"djtracker/internal/model"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const defaultListenBrainzURL = "https://api.listenbrainz.org"
+
+type listenBrainzSubmission struct {
+	ListenType string               `json:"listen_type"`
+	Payload    []listenBrainzListen `json:"payload"`
+}
+
+type listenBrainzListen struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string         `json:"artist_name"`
+	TrackName      string         `json:"track_name"`
+	ReleaseName    string         `json:"release_name,omitempty"`
+	AdditionalInfo map[string]any `json:"additional_info,omitempty"`
+}
+
+// ListenBrainz API JSON de ListenBrainz (POST /1/submit-listens, authentification par jeton utilisateur)
+type ListenBrainz struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+func NewListenBrainz(url, token string) *ListenBrainz {
+	if url == "" {
+		url = defaultListenBrainzURL
+	}
+	return &ListenBrainz{
+		client: &http.Client{Timeout: scrobbleTimeout},
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+	}
+}
+
+func (l *ListenBrainz) name() string {
+	return "listenbrainz"
+}
+
+func (l *ListenBrainz) nowPlaying(ctx context.Context, scrobble *model.Scrobble) error {
+	return l.submit(ctx, &listenBrainzSubmission{
+		ListenType: "playing_now",
+		Payload:    []listenBrainzListen{{TrackMetadata: listenBrainzMetadata(scrobble)}},
+	})
+}
+
+func (l *ListenBrainz) scrobble(ctx context.Context, scrobbles []*model.Scrobble) error {
+	submission := &listenBrainzSubmission{ListenType: "import"}
+	if len(scrobbles) == 1 {
+		submission.ListenType = "single"
+	}
+	for _, scrobble := range scrobbles {
+		submission.Payload = append(submission.Payload, listenBrainzListen{
+			ListenedAt:    scrobble.PlayedAt.Unix(),
+			TrackMetadata: listenBrainzMetadata(scrobble),
+		})
+	}
+	return l.submit(ctx, submission)
+}
+
+func (l *ListenBrainz) submit(ctx context.Context, submission *listenBrainzSubmission) error {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+l.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return statusError(resp, strings.TrimSpace(string(message)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return nil
+}
+
+func listenBrainzMetadata(scrobble *model.Scrobble) listenBrainzTrackMetadata {
+	metadata := listenBrainzTrackMetadata{
+		ArtistName: scrobble.Artist,
+		TrackName:  scrobble.Title,
+		AdditionalInfo: map[string]any{
+			"submission_client": "trackker",
+		},
+	}
+	if scrobble.Album != nil {
+		metadata.ReleaseName = *scrobble.Album
+	}
+	if scrobble.Duration > 0 {
+		metadata.AdditionalInfo["duration_ms"] = scrobble.Duration.Milliseconds()
+	}
+	return metadata
+}
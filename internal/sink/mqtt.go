@@ -0,0 +1,189 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTTopic         = "trackker/now-playing"
+	defaultMQTTProgressTopic = "trackker/progress"
+	mqttTimeout              = 5 * time.Second
+	mqttMaxReconnectInterval = 30 * time.Second
+)
+
+type progressPayload struct {
+	TrackID         int64   `json:"track_id"`
+	ElapsedSeconds  float64 `json:"elapsed_seconds"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Progress avancement entre 0 et 1, absent si la durée de la track est inconnue
+	Progress *float64 `json:"progress,omitempty"`
+}
+
+// MQTT publie la track en cours en message retenu et sa progression sur un broker MQTT.
+// La connexion est rétablie automatiquement et la track en cours republiée à chaque reconnexion.
+type MQTT struct {
+	log           *slog.Logger
+	client        mqtt.Client
+	topic         string
+	progressTopic string
+	qos           byte
+
+	mu       sync.Mutex
+	retained []byte
+	cleared  bool
+}
+
+func NewMQTT(log *slog.Logger, conf config.MQTT) (*MQTT, error) {
+	if conf.QoS > 2 {
+		return nil, fmt.Errorf("invalid mqtt qos %d, expected 0, 1 or 2", conf.QoS)
+	}
+
+	m := &MQTT{
+		log:           log,
+		topic:         conf.Topic,
+		progressTopic: conf.ProgressTopic,
+		qos:           conf.QoS,
+		cleared:       true,
+	}
+	if m.topic == "" {
+		m.topic = defaultMQTTTopic
+	}
+	if m.progressTopic == "" {
+		m.progressTopic = defaultMQTTProgressTopic
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(conf.Broker).
+		SetClientID(conf.ClientID).
+		SetUsername(conf.Username).
+		SetPassword(conf.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetMaxReconnectInterval(mqttMaxReconnectInterval).
+		// Le sous-topic status passe à offline si la connexion est perdue sans déconnexion propre
+		SetWill(m.topic+"/status", "offline", m.qos, true).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Warn("MQTT connection lost", "broker", conf.Broker, "err", err)
+		}).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			log.Info("MQTT connected", "broker", conf.Broker)
+			go m.republish()
+		})
+
+	m.client = mqtt.NewClient(options)
+	// Avec SetConnectRetry, la connexion est retentée en arrière-plan si le broker est indisponible
+	if token := m.client.Connect(); token.WaitTimeout(mqttTimeout) && token.Error() != nil {
+		return nil, fmt.Errorf("error connecting to mqtt broker %s: %w", conf.Broker, token.Error())
+	}
+	return m, nil
+}
+
+func (m *MQTT) Name() string {
+	return "mqtt"
+}
+
+// Close efface le message retenu (aucune track en cours après l'arrêt) puis se déconnecte
+func (m *MQTT) Close() error {
+	var err error
+	if m.client.IsConnectionOpen() {
+		err = errors.Join(
+			m.publish(context.Background(), m.topic, true, nil),
+			m.publish(context.Background(), m.topic+"/status", true, []byte("offline")),
+		)
+	}
+	m.client.Disconnect(uint(mqttTimeout.Milliseconds()))
+	return err
+}
+
+// Send publie la nouvelle track en message retenu, les tracks passées sur le sous-topic skipped
+func (m *MQTT) Send(ctx context.Context, event *Event) error {
+	payload, err := json.Marshal(formatter.NewTrackDTO(event.Track))
+	if err != nil {
+		return err
+	}
+
+	if event.Type == EventSkipped {
+		return m.publish(ctx, m.topic+"/skipped", false, payload)
+	}
+
+	m.mu.Lock()
+	m.retained, m.cleared = payload, false
+	m.mu.Unlock()
+	return m.publish(ctx, m.topic, true, payload)
+}
+
+// Tick publie la progression de la track en cours, et efface le message retenu une fois la track terminée
+func (m *MQTT) Tick(ctx context.Context, current *model.Track, now time.Time) error {
+	if current == nil || current.IsFinished(now) {
+		m.mu.Lock()
+		cleared := m.cleared
+		m.retained, m.cleared = nil, true
+		m.mu.Unlock()
+
+		if cleared {
+			return nil
+		}
+		return m.publish(ctx, m.topic, true, nil)
+	}
+
+	progress := &progressPayload{
+		TrackID:         current.ID,
+		ElapsedSeconds:  now.Sub(current.PlayAt).Seconds(),
+		DurationSeconds: current.Duration.Seconds(),
+	}
+	if current.Duration > 0 {
+		ratio := min(progress.ElapsedSeconds/progress.DurationSeconds, 1)
+		progress.Progress = &ratio
+	}
+
+	payload, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	// La progression n'est utile qu'en direct : pas de nouvelle tentative si le broker est injoignable
+	if !m.client.IsConnectionOpen() {
+		return nil
+	}
+	return m.publish(ctx, m.progressTopic, false, payload)
+}
+
+// republish publie l'état en ligne et la track en cours après chaque (re)connexion,
+// le broker ayant pu perdre les messages retenus
+func (m *MQTT) republish() {
+	if err := m.publish(context.Background(), m.topic+"/status", true, []byte("online")); err != nil {
+		m.log.Error("Failed to publish mqtt status", "err", err)
+	}
+
+	m.mu.Lock()
+	retained := m.retained
+	m.mu.Unlock()
+
+	if retained != nil {
+		if err := m.publish(context.Background(), m.topic, true, retained); err != nil {
+			m.log.Error("Failed to republish current track", "err", err)
+		}
+	}
+}
+
+func (m *MQTT) publish(ctx context.Context, topic string, retained bool, payload []byte) error {
+	token := m.client.Publish(topic, m.qos, retained, payload)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-token.Done():
+		return token.Error()
+	case <-time.After(mqttTimeout):
+		return errors.New("timeout publishing to " + topic)
+	}
+}
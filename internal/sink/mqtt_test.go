@@ -0,0 +1,195 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/config"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+// startBroker démarre un broker MQTT embarqué sur l'adresse donnée (port libre si vide).
+// Le broker est arrêté par la fonction retournée ou à la fin du test.
+func startBroker(t *testing.T, address string) (func(), string) {
+	t.Helper()
+	if address == "" {
+		address = "127.0.0.1:0"
+	}
+
+	server := mochi.New(&mochi.Options{Logger: discardLogger()})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	listener := listeners.NewTCP(listeners.Config{ID: "tcp", Address: address})
+	if err := server.AddListener(listener); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { _ = server.Close() })
+	}
+	t.Cleanup(stop)
+	return stop, listener.Address()
+}
+
+// subscribers numérote les clients de test, un identifiant déjà connecté déconnecterait le précédent
+var subscribers atomic.Int64
+
+type mqttMessage struct {
+	topic    string
+	payload  string
+	retained bool
+}
+
+// subscribe connecte un client de test abonné au filtre et retourne les messages reçus
+func subscribe(t *testing.T, address, filter string) chan mqttMessage {
+	t.Helper()
+	messages := make(chan mqttMessage, 32)
+	client := mqtt.NewClient(mqtt.NewClientOptions().
+		AddBroker("tcp://" + address).
+		SetClientID(fmt.Sprintf("test-%d", subscribers.Add(1))))
+	if token := client.Connect(); !token.WaitTimeout(mqttTimeout) || token.Error() != nil {
+		t.Fatalf("subscriber connection: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(100) })
+
+	token := client.Subscribe(filter, 1, func(_ mqtt.Client, message mqtt.Message) {
+		messages <- mqttMessage{message.Topic(), string(message.Payload()), message.Retained()}
+	})
+	if !token.WaitTimeout(mqttTimeout) || token.Error() != nil {
+		t.Fatalf("subscribe %s: %v", filter, token.Error())
+	}
+	return messages
+}
+
+// expectMessage attend le prochain message du topic, les autres topics sont ignorés
+func expectMessage(t *testing.T, messages chan mqttMessage, topic string) mqttMessage {
+	t.Helper()
+	timeout := time.After(mqttTimeout)
+	for {
+		select {
+		case message := <-messages:
+			if message.topic == topic {
+				return message
+			}
+		case <-timeout:
+			t.Fatalf("no message received on %s", topic)
+		}
+	}
+}
+
+func newTestMQTT(t *testing.T, address string) *MQTT {
+	t.Helper()
+	m, err := NewMQTT(discardLogger(), config.MQTT{Broker: "tcp://" + address, ClientID: "trackker-test", QoS: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMQTTPublishesCurrentTrack(t *testing.T) {
+	_, address := startBroker(t, "")
+	m := newTestMQTT(t, address)
+
+	track := testTrack()
+	if err := m.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: time.Now()}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	// Un client connecté après la publication reçoit la track en cours et l'état en ligne, retenus
+	messages := subscribe(t, address, defaultMQTTTopic+"/#")
+	status := expectMessage(t, messages, defaultMQTTTopic+"/status")
+	if status.payload != "online" || !status.retained {
+		t.Errorf("status = %+v, want retained online", status)
+	}
+	messages = subscribe(t, address, defaultMQTTTopic)
+	current := expectMessage(t, messages, defaultMQTTTopic)
+	if !current.retained {
+		t.Error("current track is not retained")
+	}
+	var payload struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(current.payload), &payload); err != nil || payload.ID != track.ID || payload.Name != track.Name {
+		t.Errorf("current track payload = %s (%v)", current.payload, err)
+	}
+
+	all := subscribe(t, address, "trackker/#")
+	skipped := *track
+	skipped.Skipped = true
+	if err := m.Send(context.Background(), &Event{Type: EventSkipped, Track: &skipped, Time: time.Now()}); err != nil {
+		t.Fatalf("Send skipped: %v", err)
+	}
+	if message := expectMessage(t, all, defaultMQTTTopic+"/skipped"); message.retained {
+		t.Error("skipped track published as retained")
+	}
+
+	if err := m.Tick(context.Background(), track, track.PlayAt.Add(time.Minute)); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	var progress progressPayload
+	message := expectMessage(t, all, defaultMQTTProgressTopic)
+	if err := json.Unmarshal([]byte(message.payload), &progress); err != nil {
+		t.Fatal(err)
+	}
+	if progress.TrackID != track.ID || progress.ElapsedSeconds != 60 || progress.Progress == nil || *progress.Progress != 0.2 {
+		t.Errorf("progress = %+v, want 60s elapsed and 0.2", progress)
+	}
+
+	// Track terminée : le message retenu est effacé
+	if err := m.Tick(context.Background(), track, track.PlayAt.Add(time.Hour)); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if message := expectMessage(t, all, defaultMQTTTopic); message.payload != "" {
+		t.Errorf("retained track not cleared after the end of the track: %q", message.payload)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if message := expectMessage(t, all, defaultMQTTTopic+"/status"); message.payload != "offline" {
+		t.Errorf("status after Close = %q, want offline", message.payload)
+	}
+}
+
+// Le broker peut perdre les messages retenus : la track en cours est republiée à la reconnexion
+func TestMQTTRepublishesAfterReconnect(t *testing.T) {
+	stopBroker, address := startBroker(t, "")
+	m := newTestMQTT(t, address)
+	defer m.Close()
+
+	track := testTrack()
+	if err := m.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: time.Now()}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	stopBroker()
+	startBroker(t, address)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !m.client.IsConnectionOpen() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !m.client.IsConnectionOpen() {
+		t.Fatal("client did not reconnect to the restarted broker")
+	}
+
+	// Le nouveau broker ne connaît la track que par la republication, reçue retenue ou en direct
+	messages := subscribe(t, address, defaultMQTTTopic)
+	current := expectMessage(t, messages, defaultMQTTTopic)
+	if current.payload == "" {
+		t.Errorf("current track not republished after reconnection: %+v", current)
+	}
+}
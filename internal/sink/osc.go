@@ -0,0 +1,137 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Adresses des messages OSC envoyés
+const (
+	oscTrackAddress    = "/trackker/track"
+	oscProgressAddress = "/trackker/progress"
+)
+
+// OSC envoie la track en cours aux logiciels de visuels en OSC 1.0 sur UDP :
+//   - /trackker/track artist (s), title (s), bpm (f), duration en secondes (f) à chaque track
+//   - /trackker/progress elapsed en secondes (f), progress entre 0 et 1 (f, -1 si durée inconnue) chaque seconde
+type OSC struct {
+	log   *slog.Logger
+	conns []net.Conn
+}
+
+func NewOSC(log *slog.Logger, conf config.OSC) (*OSC, error) {
+	o := &OSC{log: log}
+	for _, target := range conf.Targets {
+		conn, err := net.Dial("udp", target)
+		if err != nil {
+			_ = o.Close()
+			return nil, fmt.Errorf("invalid osc target %s: %w", target, err)
+		}
+		o.conns = append(o.conns, conn)
+	}
+	return o, nil
+}
+
+func (o *OSC) Name() string {
+	return "osc"
+}
+
+func (o *OSC) Close() error {
+	var errs []error
+	for _, conn := range o.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (o *OSC) Send(_ context.Context, event *Event) error {
+	if event.Type != EventTrack {
+		return nil
+	}
+
+	track := event.Track
+	artist := ""
+	if track.Artist != nil {
+		artist = *track.Artist
+	}
+	var bpm float32
+	if track.BPM != nil {
+		bpm = float32(*track.BPM)
+	}
+	return o.send(oscTrackAddress, artist, track.Name, bpm, float32(track.Duration.Seconds()))
+}
+
+func (o *OSC) Tick(_ context.Context, current *model.Track, now time.Time) error {
+	if current == nil || current.IsFinished(now) {
+		return nil
+	}
+
+	elapsed := now.Sub(current.PlayAt).Seconds()
+	progress := -1.0
+	if current.Duration > 0 {
+		progress = min(elapsed/current.Duration.Seconds(), 1)
+	}
+	return o.send(oscProgressAddress, float32(elapsed), float32(progress))
+}
+
+func (o *OSC) send(address string, args ...any) error {
+	packet, err := encodeOSCMessage(address, args...)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, conn := range o.conns {
+		// Refus signalé par ICMP quand le logiciel destinataire n'écoute pas encore : ignoré
+		if _, err := conn.Write(packet); err != nil && !errors.Is(err, syscall.ECONNREFUSED) {
+			errs = append(errs, fmt.Errorf("error sending osc message to %s: %w", conn.RemoteAddr(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// encodeOSCMessage encode un message OSC 1.0 : adresse, type tags puis arguments,
+// chaque élément aligné sur 4 octets, nombres en big-endian
+func encodeOSCMessage(address string, args ...any) ([]byte, error) {
+	var buf bytes.Buffer
+	writeOSCString(&buf, address)
+
+	tags := []byte{','}
+	var values bytes.Buffer
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			tags = append(tags, 's')
+			writeOSCString(&values, v)
+		case float32:
+			tags = append(tags, 'f')
+			_ = binary.Write(&values, binary.BigEndian, math.Float32bits(v))
+		case int32:
+			tags = append(tags, 'i')
+			_ = binary.Write(&values, binary.BigEndian, v)
+		default:
+			return nil, fmt.Errorf("unsupported osc argument type %T", arg)
+		}
+	}
+
+	writeOSCString(&buf, string(tags))
+	buf.Write(values.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writeOSCString écrit la chaîne terminée par au moins un octet nul, complétée jusqu'à un multiple de 4
+func writeOSCString(buf *bytes.Buffer, value string) {
+	buf.WriteString(value)
+	padding := 4 - len(value)%4
+	buf.Write(make([]byte, padding))
+}
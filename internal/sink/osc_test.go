@@ -0,0 +1,184 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"djtracker/internal/config"
+	"encoding/binary"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oscMessage message OSC décodé par le destinataire de test
+type oscMessage struct {
+	address string
+	args    []any
+}
+
+// decodeOSCMessage décode indépendamment de l'encodeur les types s, f et i
+func decodeOSCMessage(t *testing.T, packet []byte) oscMessage {
+	t.Helper()
+	readString := func() string {
+		end := bytes.IndexByte(packet, 0)
+		if end < 0 {
+			t.Fatalf("unterminated osc string in %q", packet)
+		}
+		value := string(packet[:end])
+		packet = packet[(end/4+1)*4:]
+		return value
+	}
+	readUint32 := func() uint32 {
+		if len(packet) < 4 {
+			t.Fatalf("truncated osc argument")
+		}
+		value := binary.BigEndian.Uint32(packet)
+		packet = packet[4:]
+		return value
+	}
+
+	message := oscMessage{address: readString()}
+	tags := readString()
+	if !strings.HasPrefix(tags, ",") {
+		t.Fatalf("invalid type tags %q", tags)
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 's':
+			message.args = append(message.args, readString())
+		case 'f':
+			message.args = append(message.args, math.Float32frombits(readUint32()))
+		case 'i':
+			message.args = append(message.args, int32(readUint32()))
+		default:
+			t.Fatalf("unexpected type tag %q", tag)
+		}
+	}
+	if len(packet) != 0 {
+		t.Fatalf("%d trailing bytes", len(packet))
+	}
+	return message
+}
+
+// listenOSC ouvre un destinataire UDP local
+func listenOSC(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func receiveOSC(t *testing.T, conn *net.UDPConn) oscMessage {
+	t.Helper()
+	buf := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no osc message received: %v", err)
+	}
+	return decodeOSCMessage(t, buf[:n])
+}
+
+func expectNoOSC(t *testing.T, conn *net.UDPConn) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 1024)); err == nil {
+		t.Errorf("unexpected osc message of %d bytes", n)
+	}
+}
+
+func TestEncodeOSCMessage(t *testing.T) {
+	// Exemple de la spécification OSC 1.0
+	packet, err := encodeOSCMessage("/oscillator/4/frequency", float32(440))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("/oscillator/4/frequency\x00,f\x00\x00\x43\xdc\x00\x00")
+	if !bytes.Equal(packet, want) {
+		t.Errorf("encodeOSCMessage = %q, want %q", packet, want)
+	}
+
+	if _, err := encodeOSCMessage("/x", 1.5); err == nil {
+		t.Error("float64 argument accepted")
+	}
+}
+
+func TestOSCSendsTrackAndProgress(t *testing.T) {
+	first, second := listenOSC(t), listenOSC(t)
+	o, err := NewOSC(discardLogger(), config.OSC{Targets: []string{first.LocalAddr().String(), second.LocalAddr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	track := testTrack()
+	track.BPM = ptr(124.0)
+	if err := o.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: time.Now()}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for _, conn := range []*net.UDPConn{first, second} {
+		message := receiveOSC(t, conn)
+		if message.address != oscTrackAddress || len(message.args) != 4 ||
+			message.args[0] != "Daft Punk" || message.args[1] != "One More Time" ||
+			message.args[2] != float32(124) || message.args[3] != float32(300) {
+			t.Errorf("track message = %+v", message)
+		}
+	}
+
+	// Les tracks passées ne sont pas envoyées
+	if err := o.Send(context.Background(), &Event{Type: EventSkipped, Track: track, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	expectNoOSC(t, first)
+
+	if err := o.Tick(context.Background(), track, track.PlayAt.Add(75*time.Second)); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	message := receiveOSC(t, first)
+	if message.address != oscProgressAddress || len(message.args) != 2 ||
+		message.args[0] != float32(75) || message.args[1] != float32(0.25) {
+		t.Errorf("progress message = %+v", message)
+	}
+	receiveOSC(t, second)
+
+	unknown := testTrack()
+	unknown.Duration = 0
+	if err := o.Tick(context.Background(), unknown, unknown.PlayAt.Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if message := receiveOSC(t, first); message.args[1] != float32(-1) {
+		t.Errorf("progress with unknown duration = %v, want -1", message.args[1])
+	}
+	receiveOSC(t, second)
+
+	// Track terminée : plus de progression
+	if err := o.Tick(context.Background(), track, track.PlayAt.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	expectNoOSC(t, first)
+}
+
+// Un logiciel de visuels pas encore lancé ne fait pas échouer l'envoi
+func TestOSCIgnoresClosedTarget(t *testing.T) {
+	closed := listenOSC(t)
+	address := closed.LocalAddr().String()
+	_ = closed.Close()
+
+	o, err := NewOSC(discardLogger(), config.OSC{Targets: []string{address}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	for range 3 {
+		if err := o.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err != nil {
+			t.Errorf("Send to a closed port: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,65 @@
+package sink
+
+import (
+	"djtracker/internal/config"
+	"djtracker/internal/repository"
+	"log/slog"
+)
+
+// New crée les sorties déclarées dans la configuration (section outputs)
+func New(conf *config.Config, log *slog.Logger, store repository.Store) ([]Sink, error) {
+	var sinks []Sink
+
+	for _, webhookConf := range conf.Outputs.Webhooks {
+		webhook, err := NewWebhook(log, store, webhookConf)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, webhook)
+	}
+
+	if conf.Outputs.MQTT.Broker != "" {
+		publisher, err := NewMQTT(log, conf.Outputs.MQTT)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, publisher)
+	}
+
+	if conf.Outputs.Files.Directory != "" {
+		files, err := NewFiles(log, conf.Outputs.Files)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, files)
+	}
+
+	if len(conf.Outputs.OSC.Targets) > 0 {
+		sender, err := NewOSC(log, conf.Outputs.OSC)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sender)
+	}
+
+	if conf.Outputs.Icecast.URL != "" {
+		updater, err := NewIcecast(log, conf.Outputs.Icecast)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, updater)
+	}
+
+	var services []scrobbleService
+	if listenBrainz := conf.Outputs.Scrobble.ListenBrainz; listenBrainz.Token != "" {
+		services = append(services, NewListenBrainz(listenBrainz.URL, listenBrainz.Token))
+	}
+	if lastFM := conf.Outputs.Scrobble.LastFM; lastFM.APIKey != "" && lastFM.SessionKey != "" {
+		services = append(services, NewLastFM(lastFM.URL, lastFM.APIKey, lastFM.Secret, lastFM.SessionKey))
+	}
+	if len(services) > 0 {
+		sinks = append(sinks, NewScrobbler(log, store, services))
+	}
+
+	return sinks, nil
+}
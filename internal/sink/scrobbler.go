@@ -0,0 +1,223 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// scrobbleMinDuration durée en dessous de laquelle une track n'est pas scrobblée
+	scrobbleMinDuration = 30 * time.Second
+	// scrobbleMaxDelay durée de lecture suffisante quelle que soit la durée de la track
+	scrobbleMaxDelay = 4 * time.Minute
+	// scrobbleBatchSize nombre maximal de lectures envoyées par requête (limite de Last.fm)
+	scrobbleBatchSize = 50
+	scrobbleTimeout   = 15 * time.Second
+	// scrobbleRetry délai avant de renvoyer la file après un échec, doublé jusqu'à scrobbleMaxRetry
+	scrobbleRetry    = 30 * time.Second
+	scrobbleMaxRetry = 30 * time.Minute
+)
+
+// scrobbleService API d'un service de scrobbling
+type scrobbleService interface {
+	name() string
+	nowPlaying(ctx context.Context, scrobble *model.Scrobble) error
+	// scrobble envoie un lot de lectures, au plus scrobbleBatchSize
+	scrobble(ctx context.Context, scrobbles []*model.Scrobble) error
+}
+
+// permanentError échec qu'un nouvel essai ne corrigera pas (requête refusée par le service)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// statusError retourne l'erreur correspondant à une réponse HTTP en échec, permanente sauf erreur serveur ou limite de débit
+func statusError(resp *http.Response, message string) error {
+	err := fmt.Errorf("%s: %s", resp.Status, message)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return &permanentError{err}
+}
+
+// serviceQueue état de la file d'envoi d'un service
+type serviceQueue struct {
+	service scrobbleService
+	next    time.Time
+	backoff time.Duration
+}
+
+// Scrobbler envoie les lectures aux services de scrobbling : « en écoute » au lancement de la track,
+// puis scrobble une fois la moitié de la track (ou 4 minutes) écoutée. Les scrobbles passent par une file
+// en base renvoyée régulièrement, pour ne rien perdre quand le lieu n'a pas de réseau.
+type Scrobbler struct {
+	log    *slog.Logger
+	store  repository.ScrobbleStore
+	queues []*serviceQueue
+
+	// pending track en cours, en attente du seuil de scrobble
+	pending *model.Track
+}
+
+func NewScrobbler(log *slog.Logger, store repository.ScrobbleStore, services []scrobbleService) *Scrobbler {
+	s := &Scrobbler{
+		log:   log,
+		store: store,
+	}
+	for _, service := range services {
+		s.queues = append(s.queues, &serviceQueue{service: service})
+	}
+	return s
+}
+
+func (s *Scrobbler) Name() string {
+	return "scrobbler"
+}
+
+func (s *Scrobbler) Close() error {
+	return nil
+}
+
+func (s *Scrobbler) Send(ctx context.Context, event *Event) error {
+	track := event.Track
+	switch event.Type {
+	case EventSkipped:
+		if s.pending != nil && s.pending.ID == track.ID {
+			s.pending = nil
+		}
+		return nil
+	case EventTrack:
+		s.pending = nil
+		if track.Artist == nil || track.Name == "" {
+			s.log.Debug("Track without artist or title is not scrobbled", "track", track.ID)
+			return nil
+		}
+		if track.Duration > 0 && track.Duration < scrobbleMinDuration {
+			return nil
+		}
+		s.pending = track
+
+		var errs []error
+		for _, queue := range s.queues {
+			if err := queue.service.nowPlaying(ctx, newScrobble(queue.service.name(), track)); err != nil {
+				errs = append(errs, fmt.Errorf("%s now playing: %w", queue.service.name(), err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// Tick ajoute la track en cours à la file une fois le seuil atteint, puis envoie les files arrivées à échéance
+func (s *Scrobbler) Tick(ctx context.Context, current *model.Track, now time.Time) error {
+	var errs []error
+	if s.pending != nil && current != nil && current.ID == s.pending.ID && now.Sub(s.pending.PlayAt) >= scrobbleThreshold(s.pending) {
+		for _, queue := range s.queues {
+			scrobble := newScrobble(queue.service.name(), s.pending)
+			if err := s.store.AddScrobble(scrobble); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			queue.next = now
+		}
+		s.pending = nil
+	}
+
+	for _, queue := range s.queues {
+		if now.Before(queue.next) {
+			continue
+		}
+		errs = append(errs, s.flush(ctx, queue, now))
+	}
+	return errors.Join(errs...)
+}
+
+// flush envoie les lectures en attente du service par lots.
+// Un échec temporaire (réseau, erreur serveur) repousse l'envoi, un refus du service écarte le lot.
+func (s *Scrobbler) flush(ctx context.Context, queue *serviceQueue, now time.Time) error {
+	name := queue.service.name()
+	for {
+		scrobbles, err := s.store.FindQueuedScrobbles(name, scrobbleBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(scrobbles) == 0 {
+			queue.next, queue.backoff = now.Add(scrobbleRetry), 0
+			return nil
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, scrobbleTimeout)
+		sendErr := queue.service.scrobble(sendCtx, scrobbles)
+		cancel()
+
+		var permanent *permanentError
+		retry := sendErr != nil && !errors.As(sendErr, &permanent)
+		for _, scrobble := range scrobbles {
+			scrobble.Attempts++
+			switch {
+			case sendErr == nil:
+				sentAt := time.Now()
+				scrobble.Status, scrobble.SentAt, scrobble.Error = model.ScrobbleSent, &sentAt, nil
+			case retry:
+				message := sendErr.Error()
+				scrobble.Error = &message
+			default:
+				message := sendErr.Error()
+				scrobble.Status, scrobble.Error = model.ScrobbleFailed, &message
+			}
+			if err := s.store.UpdateScrobble(scrobble); err != nil {
+				return err
+			}
+		}
+
+		if retry {
+			queue.backoff = min(max(queue.backoff*2, scrobbleRetry), scrobbleMaxRetry)
+			queue.next = now.Add(queue.backoff)
+			s.log.Warn("Scrobbling failed, queue kept for later", "service", name, "queued", len(scrobbles), "retry_in", queue.backoff, "err", sendErr)
+			return nil
+		}
+		if sendErr != nil {
+			s.log.Error("Scrobbles rejected by service", "service", name, "count", len(scrobbles), "err", sendErr)
+		}
+		if len(scrobbles) < scrobbleBatchSize {
+			queue.next, queue.backoff = now.Add(scrobbleRetry), 0
+			return nil
+		}
+	}
+}
+
+// scrobbleThreshold durée d'écoute avant scrobble : la moitié de la track, au plus 4 minutes
+func scrobbleThreshold(track *model.Track) time.Duration {
+	if track.Duration <= 0 {
+		return scrobbleMaxDelay
+	}
+	return min(track.Duration/2, scrobbleMaxDelay)
+}
+
+func newScrobble(service string, track *model.Track) *model.Scrobble {
+	return &model.Scrobble{
+		Service:   service,
+		TrackID:   track.ID,
+		Artist:    valueOrEmpty(track.Artist),
+		Title:     track.Name,
+		Album:     track.Album,
+		Duration:  track.Duration,
+		PlayedAt:  track.PlayAt,
+		Status:    model.ScrobbleQueued,
+		CreatedAt: time.Now(),
+	}
+}
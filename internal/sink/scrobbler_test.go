@@ -0,0 +1,441 @@
+package sink
+
+import (
+	"context"
+	"crypto/md5"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testLastFMKey     = "api-key"
+	testLastFMSecret  = "api-secret"
+	testLastFMSession = "session-key"
+	testLBToken       = "lb-token"
+)
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+// fakeScrobbleServer enregistre les requêtes reçues et répond les réponses programmées, puis 200
+type fakeScrobbleServer struct {
+	t        *testing.T
+	mu       sync.Mutex
+	replies  []fakeResponse
+	requests []url.Values
+	bodies   []listenBrainzSubmission
+}
+
+func (s *fakeScrobbleServer) reply(responses ...fakeResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, responses...)
+}
+
+func (s *fakeScrobbleServer) next() fakeResponse {
+	if len(s.replies) == 0 {
+		return fakeResponse{http.StatusOK, "{}"}
+	}
+	response := s.replies[0]
+	s.replies = s.replies[1:]
+	return response
+}
+
+func (s *fakeScrobbleServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests) + len(s.bodies)
+}
+
+// lastFMHandler vérifie la signature de chaque appel comme le ferait Last.fm
+func (s *fakeScrobbleServer) lastFMHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.t.Errorf("invalid form: %v", err)
+	}
+	form := r.PostForm
+
+	s.mu.Lock()
+	s.requests = append(s.requests, form)
+	response := s.next()
+	s.mu.Unlock()
+
+	if form.Get("api_key") != testLastFMKey || form.Get("sk") != testLastFMSession || form.Get("format") != "json" {
+		s.t.Errorf("missing credentials in %v", form)
+	}
+	if got, want := form.Get("api_sig"), expectedSignature(form, testLastFMSecret); got != want {
+		s.t.Errorf("api_sig = %s, want %s", got, want)
+		response = fakeResponse{http.StatusForbidden, `{"error":13,"message":"Invalid method signature supplied"}`}
+	}
+
+	w.WriteHeader(response.status)
+	_, _ = io.WriteString(w, response.body)
+}
+
+func (s *fakeScrobbleServer) listenBrainzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/1/submit-listens" || r.Method != http.MethodPost {
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}
+	if got := r.Header.Get("Authorization"); got != "Token "+testLBToken {
+		s.t.Errorf("Authorization = %q", got)
+	}
+	var submission listenBrainzSubmission
+	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
+		s.t.Errorf("invalid submission: %v", err)
+	}
+
+	s.mu.Lock()
+	s.bodies = append(s.bodies, submission)
+	response := s.next()
+	s.mu.Unlock()
+
+	w.WriteHeader(response.status)
+	_, _ = io.WriteString(w, response.body)
+}
+
+// expectedSignature recalcule api_sig selon la documentation Last.fm
+func expectedSignature(form url.Values, secret string) string {
+	var keys []string
+	for key := range form {
+		if key != "api_sig" && key != "format" && key != "callback" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + form.Get(key))
+	}
+	sum := md5.Sum([]byte(b.String() + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newFakeLastFM(t *testing.T) (*fakeScrobbleServer, *LastFM) {
+	fake := &fakeScrobbleServer{t: t}
+	server := httptest.NewServer(http.HandlerFunc(fake.lastFMHandler))
+	t.Cleanup(server.Close)
+	return fake, NewLastFM(server.URL, testLastFMKey, testLastFMSecret, testLastFMSession)
+}
+
+func newFakeListenBrainz(t *testing.T) (*fakeScrobbleServer, *ListenBrainz) {
+	fake := &fakeScrobbleServer{t: t}
+	server := httptest.NewServer(http.HandlerFunc(fake.listenBrainzHandler))
+	t.Cleanup(server.Close)
+	return fake, NewListenBrainz(server.URL+"/", testLBToken)
+}
+
+func queuedScrobble(service string) *model.Scrobble {
+	return newScrobble(service, testTrack())
+}
+
+func TestLastFMSign(t *testing.T) {
+	// Exemple de la documentation : api_keyxxxxxxxxmethodauth.getSessiontokenyyyyyy suivi du secret
+	params := url.Values{}
+	params.Set("api_key", "xxxxxxxx")
+	params.Set("method", "auth.getSession")
+	params.Set("token", "yyyyyy")
+	params.Set("format", "json")
+
+	sum := md5.Sum([]byte("api_keyxxxxxxxxmethodauth.getSessiontokenyyyyyy" + "ilovecher"))
+	lastFM := NewLastFM("", "xxxxxxxx", "ilovecher", "")
+	if got, want := lastFM.sign(params), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("sign = %s, want %s", got, want)
+	}
+}
+
+func TestLastFMCalls(t *testing.T) {
+	fake, lastFM := newFakeLastFM(t)
+
+	if err := lastFM.nowPlaying(context.Background(), queuedScrobble("lastfm")); err != nil {
+		t.Fatalf("nowPlaying: %v", err)
+	}
+	first, second := queuedScrobble("lastfm"), queuedScrobble("lastfm")
+	second.Title, second.Album = "Aerodynamic", nil
+	if err := lastFM.scrobble(context.Background(), []*model.Scrobble{first, second}); err != nil {
+		t.Fatalf("scrobble: %v", err)
+	}
+
+	nowPlaying, scrobble := fake.requests[0], fake.requests[1]
+	if nowPlaying.Get("method") != "track.updateNowPlaying" || nowPlaying.Get("artist") != "Daft Punk" ||
+		nowPlaying.Get("track") != "One More Time" || nowPlaying.Get("album") != "Discovery" || nowPlaying.Get("duration") != "300" {
+		t.Errorf("updateNowPlaying = %v", nowPlaying)
+	}
+	if scrobble.Get("method") != "track.scrobble" || scrobble.Get("track[1]") != "Aerodynamic" ||
+		scrobble.Get("timestamp[0]") == "" || scrobble.Has("album[1]") {
+		t.Errorf("scrobble = %v", scrobble)
+	}
+}
+
+func TestLastFMErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  fakeResponse
+		permanent bool
+	}{
+		{"service offline", fakeResponse{http.StatusOK, `{"error":11,"message":"Service Offline"}`}, false},
+		{"rate limit", fakeResponse{http.StatusOK, `{"error":29,"message":"Rate limit exceeded"}`}, false},
+		{"temporary error", fakeResponse{http.StatusServiceUnavailable, `{"error":16,"message":"Try again"}`}, false},
+		{"invalid session", fakeResponse{http.StatusForbidden, `{"error":9,"message":"Invalid session key"}`}, true},
+		{"bad gateway", fakeResponse{http.StatusBadGateway, `<html>proxy error</html>`}, false},
+		{"not found", fakeResponse{http.StatusNotFound, `not found`}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, lastFM := newFakeLastFM(t)
+			fake.reply(tt.response)
+
+			err := lastFM.scrobble(context.Background(), []*model.Scrobble{queuedScrobble("lastfm")})
+			if err == nil {
+				t.Fatal("scrobble succeeded")
+			}
+			var permanent *permanentError
+			if errors.As(err, &permanent) != tt.permanent {
+				t.Errorf("error %v permanent = %v, want %v", err, !tt.permanent, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestListenBrainzSubmissions(t *testing.T) {
+	fake, listenBrainz := newFakeListenBrainz(t)
+
+	if err := listenBrainz.nowPlaying(context.Background(), queuedScrobble("listenbrainz")); err != nil {
+		t.Fatalf("nowPlaying: %v", err)
+	}
+	single := queuedScrobble("listenbrainz")
+	if err := listenBrainz.scrobble(context.Background(), []*model.Scrobble{single}); err != nil {
+		t.Fatalf("scrobble: %v", err)
+	}
+	if err := listenBrainz.scrobble(context.Background(), []*model.Scrobble{single, single}); err != nil {
+		t.Fatalf("scrobble: %v", err)
+	}
+
+	nowPlaying := fake.bodies[0]
+	if nowPlaying.ListenType != "playing_now" || len(nowPlaying.Payload) != 1 || nowPlaying.Payload[0].ListenedAt != 0 {
+		t.Errorf("playing_now submission = %+v", nowPlaying)
+	}
+	listen := fake.bodies[1]
+	if listen.ListenType != "single" || listen.Payload[0].ListenedAt != single.PlayedAt.Unix() {
+		t.Errorf("single submission = %+v", listen)
+	}
+	metadata := listen.Payload[0].TrackMetadata
+	if metadata.ArtistName != "Daft Punk" || metadata.TrackName != "One More Time" || metadata.ReleaseName != "Discovery" ||
+		metadata.AdditionalInfo["duration_ms"] != float64(300000) || metadata.AdditionalInfo["submission_client"] != "trackker" {
+		t.Errorf("track metadata = %+v", metadata)
+	}
+	if batch := fake.bodies[2]; batch.ListenType != "import" || len(batch.Payload) != 2 {
+		t.Errorf("import submission = %+v", batch)
+	}
+
+	fake.reply(fakeResponse{http.StatusTooManyRequests, "slow down"}, fakeResponse{http.StatusUnauthorized, "invalid token"})
+	var permanent *permanentError
+	if err := listenBrainz.scrobble(context.Background(), []*model.Scrobble{single}); err == nil || errors.As(err, &permanent) {
+		t.Errorf("429 error = %v, want retryable", err)
+	}
+	if err := listenBrainz.scrobble(context.Background(), []*model.Scrobble{single}); !errors.As(err, &permanent) {
+		t.Errorf("401 error = %v, want permanent", err)
+	}
+}
+
+func queuedScrobbles(t *testing.T, store *repository.Memory, service string) []*model.Scrobble {
+	t.Helper()
+	// La mémoire ne propose que la file : les scrobbles envoyés ou refusés en sortent
+	queued, err := store.FindQueuedScrobbles(service, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queued
+}
+
+func TestScrobblerScrobblesAfterThreshold(t *testing.T) {
+	lastFMServer, lastFM := newFakeLastFM(t)
+	lbServer, listenBrainz := newFakeListenBrainz(t)
+	store := repository.NewMemory()
+	scrobbler := NewScrobbler(discardLogger(), store, []scrobbleService{lastFM, listenBrainz})
+
+	track := testTrack()
+	track.Duration = 4 * time.Minute
+	if err := scrobbler.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: track.PlayAt}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if lastFMServer.count() != 1 || lbServer.count() != 1 {
+		t.Fatalf("now playing requests = %d/%d, want 1 per service", lastFMServer.count(), lbServer.count())
+	}
+
+	// Moitié de la track non atteinte
+	if err := scrobbler.Tick(context.Background(), track, track.PlayAt.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if lastFMServer.count() != 1 {
+		t.Fatal("scrobbled before half of the track")
+	}
+
+	if err := scrobbler.Tick(context.Background(), track, track.PlayAt.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if lastFMServer.count() != 2 || lbServer.count() != 2 {
+		t.Fatalf("scrobble requests = %d/%d, want 1 per service", lastFMServer.count()-1, lbServer.count()-1)
+	}
+	if queued := queuedScrobbles(t, store, "lastfm"); len(queued) != 0 {
+		t.Errorf("%d scrobbles still queued after success", len(queued))
+	}
+}
+
+func TestScrobblerIgnoresSkippedAndShortTracks(t *testing.T) {
+	lastFMServer, lastFM := newFakeLastFM(t)
+	store := repository.NewMemory()
+	scrobbler := NewScrobbler(discardLogger(), store, []scrobbleService{lastFM})
+
+	short := testTrack()
+	short.Duration = 20 * time.Second
+	if err := scrobbler.Send(context.Background(), &Event{Type: EventTrack, Track: short, Time: short.PlayAt}); err != nil {
+		t.Fatal(err)
+	}
+	if lastFMServer.count() != 0 {
+		t.Error("track shorter than 30 seconds sent as now playing")
+	}
+
+	track := testTrack()
+	if err := scrobbler.Send(context.Background(), &Event{Type: EventTrack, Track: track, Time: track.PlayAt}); err != nil {
+		t.Fatal(err)
+	}
+	skipped := *track
+	skipped.Skipped = true
+	if err := scrobbler.Send(context.Background(), &Event{Type: EventSkipped, Track: &skipped, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := scrobbler.Tick(context.Background(), track, track.PlayAt.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if queued := queuedScrobbles(t, store, "lastfm"); len(queued) != 0 || lastFMServer.count() != 1 {
+		t.Errorf("skipped track scrobbled (%d queued, %d requests)", len(queued), lastFMServer.count())
+	}
+}
+
+func TestScrobblerBackoff(t *testing.T) {
+	lastFMServer, lastFM := newFakeLastFM(t)
+	store := repository.NewMemory()
+	scrobbler := NewScrobbler(discardLogger(), store, []scrobbleService{lastFM})
+
+	// File laissée par une coupure réseau précédente
+	if err := store.AddScrobble(queuedScrobble("lastfm")); err != nil {
+		t.Fatal(err)
+	}
+	lastFMServer.reply(
+		fakeResponse{http.StatusOK, `{"error":11,"message":"Service Offline"}`},
+		fakeResponse{http.StatusServiceUnavailable, "unavailable"},
+	)
+
+	now := time.Now()
+	tick := func(at time.Duration) {
+		t.Helper()
+		if err := scrobbler.Tick(context.Background(), nil, now.Add(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		at       time.Duration
+		requests int
+		queued   bool
+	}{
+		{0, 1, true},                 // échec : nouvel essai dans 30 s
+		{29 * time.Second, 1, true},  // pas encore
+		{30 * time.Second, 2, true},  // échec : nouvel essai dans 60 s
+		{89 * time.Second, 2, true},  // pas encore
+		{90 * time.Second, 3, false}, // envoyé
+	}
+	for _, step := range steps {
+		tick(step.at)
+		if got := lastFMServer.count(); got != step.requests {
+			t.Fatalf("at %s: %d requests, want %d", step.at, got, step.requests)
+		}
+		queued := queuedScrobbles(t, store, "lastfm")
+		if (len(queued) == 1) != step.queued {
+			t.Fatalf("at %s: %d queued, want queued %v", step.at, len(queued), step.queued)
+		}
+		if step.queued && queued[0].Attempts != step.requests {
+			t.Errorf("at %s: attempts = %d, want %d", step.at, queued[0].Attempts, step.requests)
+		}
+	}
+
+	// Backoff remis à zéro après succès : la file vide est relue 30 s plus tard
+	queue := scrobbler.queues[0]
+	if queue.backoff != 0 || !queue.next.Equal(now.Add(120*time.Second)) {
+		t.Errorf("queue after success = next %s, backoff %s", queue.next.Sub(now), queue.backoff)
+	}
+}
+
+func TestScrobblerBackoffIsCapped(t *testing.T) {
+	lastFMServer, lastFM := newFakeLastFM(t)
+	store := repository.NewMemory()
+	scrobbler := NewScrobbler(discardLogger(), store, []scrobbleService{lastFM})
+	if err := store.AddScrobble(queuedScrobble("lastfm")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute,
+		16 * time.Minute, 30 * time.Minute, 30 * time.Minute}
+	now := time.Now()
+	queue := scrobbler.queues[0]
+	for i, backoff := range want {
+		lastFMServer.reply(fakeResponse{http.StatusBadGateway, "bad gateway"})
+		if err := scrobbler.Tick(context.Background(), nil, now); err != nil {
+			t.Fatal(err)
+		}
+		if queue.backoff != backoff || !queue.next.Equal(now.Add(backoff)) {
+			t.Fatalf("failure %d: backoff %s, want %s", i+1, queue.backoff, backoff)
+		}
+		now = queue.next
+	}
+}
+
+// updateRecorder garde la dernière version de chaque scrobble mis à jour
+type updateRecorder struct {
+	*repository.Memory
+	updated map[int64]model.Scrobble
+}
+
+func (r *updateRecorder) UpdateScrobble(scrobble *model.Scrobble) error {
+	r.updated[scrobble.ID] = *scrobble
+	return r.Memory.UpdateScrobble(scrobble)
+}
+
+func TestScrobblerDropsRejectedScrobbles(t *testing.T) {
+	lastFMServer, lastFM := newFakeLastFM(t)
+	store := &updateRecorder{Memory: repository.NewMemory(), updated: map[int64]model.Scrobble{}}
+	scrobbler := NewScrobbler(discardLogger(), store, []scrobbleService{lastFM})
+
+	scrobble := queuedScrobble("lastfm")
+	if err := store.AddScrobble(scrobble); err != nil {
+		t.Fatal(err)
+	}
+	lastFMServer.reply(fakeResponse{http.StatusForbidden, `{"error":9,"message":"Invalid session key"}`})
+
+	if err := scrobbler.Tick(context.Background(), nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if queued := queuedScrobbles(t, store.Memory, "lastfm"); len(queued) != 0 {
+		t.Fatalf("rejected scrobble kept in queue")
+	}
+	rejected, ok := store.updated[scrobble.ID]
+	if !ok || rejected.Status != model.ScrobbleFailed || rejected.Error == nil || !strings.Contains(*rejected.Error, "Invalid session key") {
+		t.Errorf("rejected scrobble = %s (%v)", rejected.Status, rejected.Error)
+	}
+	if queue := scrobbler.queues[0]; queue.backoff != 0 {
+		t.Errorf("backoff = %s after a permanent error, want no retry delay", queue.backoff)
+	}
+}
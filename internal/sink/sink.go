@@ -0,0 +1,209 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/metrics"
+	"djtracker/internal/model"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Types d'événements transmis aux sorties
+const (
+	// EventTrack nouvelle track jouée
+	EventTrack = "track"
+	// EventSkipped track précédente finalement passée (voir model.Track.Skipped)
+	EventSkipped = "skipped"
+)
+
+const (
+	// queueSize nombre d'événements en attente par sortie avant d'en abandonner
+	queueSize = 16
+	// tickInterval fréquence d'appel des sorties implémentant Ticker
+	tickInterval = time.Second
+)
+
+// Event événement du tracker transmis aux sorties
+type Event struct {
+	Type  string
+	Track *model.Track
+	Time  time.Time
+}
+
+// Sink sortie vers laquelle les événements du tracker sont transmis (webhook, MQTT, fichiers...).
+// Send est appelé depuis une goroutine dédiée à la sortie, dans l'ordre des événements :
+// une sortie lente ne retarde ni le tracker ni les autres sorties.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// Ticker est implémenté par les sorties ayant besoin d'un appel régulier,
+// par exemple pour publier la progression de la track en cours (nil si aucune)
+type Ticker interface {
+	Tick(ctx context.Context, current *model.Track, now time.Time) error
+}
+
+// Source flux d'événements du tracker (voir service.Tracker)
+type Source interface {
+	SubscribeForTracks() (chan *model.Track, func())
+	SubscribeForCorrections() (chan *model.Track, func())
+	GetCurrentTrack() *model.Track
+}
+
+// Dispatcher transmet les événements du tracker à chaque sortie configurée
+type Dispatcher struct {
+	log   *slog.Logger
+	sinks []Sink
+	wg    sync.WaitGroup
+}
+
+func NewDispatcher(log *slog.Logger, sinks []Sink) *Dispatcher {
+	return &Dispatcher{
+		log:   log,
+		sinks: sinks,
+	}
+}
+
+// Start S'abonne aux événements du tracker et les transmet aux sorties jusqu'à la fermeture
+// des abonnements (arrêt du tracker). Les envois en cours sont annulés avec le contexte.
+// À appeler après service.Tracker.StartTracking : la track en cours, reprise en base au démarrage
+// du tracker, sert d'état initial aux sorties (voir Ticker).
+func (d *Dispatcher) Start(ctx context.Context, source Source) {
+	if len(d.sinks) == 0 {
+		return
+	}
+
+	// Abonnement avant la lecture de la track en cours : une track reçue entre-temps n'est pas perdue
+	tracks, unsubscribeTracks := source.SubscribeForTracks()
+	corrections, unsubscribeCorrections := source.SubscribeForCorrections()
+	current := source.GetCurrentTrack()
+
+	queues := make([]chan *Event, len(d.sinks))
+	for i, s := range d.sinks {
+		queues[i] = make(chan *Event, queueSize)
+		d.wg.Add(1)
+		go d.run(ctx, s, queues[i], current)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer unsubscribeTracks()
+		defer unsubscribeCorrections()
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+		}()
+
+		for tracks != nil || corrections != nil {
+			var event *Event
+			select {
+			case track, ok := <-tracks:
+				if !ok {
+					tracks = nil
+					continue
+				}
+				event = &Event{Type: EventTrack, Track: track, Time: time.Now()}
+			case track, ok := <-corrections:
+				if !ok {
+					corrections = nil
+					continue
+				}
+				event = &Event{Type: EventSkipped, Track: track, Time: time.Now()}
+			}
+
+			for i, queue := range queues {
+				select {
+				case queue <- event:
+				default:
+					d.log.Warn("Output queue full, event dropped", "sink", d.sinks[i].Name(), "type", event.Type)
+					metrics.SinkEventsDropped.WithLabelValues(d.sinks[i].Name()).Inc()
+				}
+			}
+		}
+	}()
+}
+
+// Wait Bloque jusqu'à ce que toutes les sorties aient traité leurs événements et soient fermées
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run(ctx context.Context, s Sink, queue chan *Event, current *model.Track) {
+	defer d.wg.Done()
+	defer func() {
+		if err := s.Close(); err != nil {
+			d.log.Error("Failed to close output", "sink", s.Name(), "err", err)
+		}
+	}()
+
+	ticker, _ := s.(Ticker)
+	var ticks <-chan time.Time
+	if ticker != nil {
+		t := time.NewTicker(tickInterval)
+		defer t.Stop()
+		ticks = t.C
+	}
+
+	for {
+		select {
+		case event, ok := <-queue:
+			if !ok {
+				return
+			}
+			if event.Type == EventTrack {
+				current = event.Track
+			}
+			if err := s.Send(ctx, event); err != nil {
+				d.log.Error("Failed to send event to output", "sink", s.Name(), "type", event.Type, "err", err)
+				metrics.SinkErrors.WithLabelValues(s.Name()).Inc()
+			}
+		case now := <-ticks:
+			if err := ticker.Tick(ctx, current, now); err != nil {
+				d.log.Error("Output tick failed", "sink", s.Name(), "err", err)
+				metrics.SinkErrors.WithLabelValues(s.Name()).Inc()
+			}
+		}
+	}
+}
+
+// trackData valeurs disponibles dans les templates des sorties, les champs inconnus valent une chaîne vide
+type trackData struct {
+	Artist   string
+	Title    string
+	Remix    string
+	Album    string
+	Genre    string
+	Key      string
+	BPM      float64
+	Duration time.Duration
+	Track    *model.Track
+}
+
+func newTrackData(track *model.Track) *trackData {
+	data := &trackData{
+		Artist:   valueOrEmpty(track.Artist),
+		Title:    track.Name,
+		Remix:    valueOrEmpty(track.Remix),
+		Album:    valueOrEmpty(track.Album),
+		Genre:    valueOrEmpty(track.Genre),
+		Key:      valueOrEmpty(track.Key),
+		Duration: track.Duration,
+		Track:    track,
+	}
+	if track.BPM != nil {
+		data.BPM = *track.BPM
+	}
+	return data
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,144 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/model"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testTrack() *model.Track {
+	return &model.Track{
+		ID:       12,
+		EventID:  3,
+		Artist:   ptr("Daft Punk"),
+		Name:     "One More Time",
+		Album:    ptr("Discovery"),
+		PlayAt:   time.Now().Add(-time.Minute),
+		Duration: 5 * time.Minute,
+		Path:     "/music/one-more-time.mp3",
+	}
+}
+
+// fakeSource remplace le tracker : la track en cours et les abonnements sont fournis par le test
+type fakeSource struct {
+	current     *model.Track
+	tracks      chan *model.Track
+	corrections chan *model.Track
+}
+
+func newFakeSource(current *model.Track) *fakeSource {
+	return &fakeSource{
+		current:     current,
+		tracks:      make(chan *model.Track, 1),
+		corrections: make(chan *model.Track, 1),
+	}
+}
+
+func (s *fakeSource) SubscribeForTracks() (chan *model.Track, func()) {
+	return s.tracks, func() {}
+}
+
+func (s *fakeSource) SubscribeForCorrections() (chan *model.Track, func()) {
+	return s.corrections, func() {}
+}
+
+func (s *fakeSource) GetCurrentTrack() *model.Track {
+	return s.current
+}
+
+func (s *fakeSource) stop() {
+	close(s.tracks)
+	close(s.corrections)
+}
+
+// recordingSink garde les événements et la track vue par Tick
+type recordingSink struct {
+	mu     sync.Mutex
+	events []*Event
+	ticked chan *model.Track
+	closed bool
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{ticked: make(chan *model.Track, 16)}
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(_ context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) Tick(_ context.Context, current *model.Track, _ time.Time) error {
+	select {
+	case s.ticked <- current:
+	default:
+	}
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Après un redémarrage, les sorties reçoivent la track en cours reprise par le tracker
+func TestDispatcherSeedsCurrentTrack(t *testing.T) {
+	current := testTrack()
+	source := newFakeSource(current)
+	recorder := newRecordingSink()
+
+	dispatcher := NewDispatcher(discardLogger(), []Sink{recorder})
+	dispatcher.Start(context.Background(), source)
+
+	select {
+	case got := <-recorder.ticked:
+		if got != current {
+			t.Errorf("first tick current track = %v, want the track loaded on start", got)
+		}
+	case <-time.After(3 * tickInterval):
+		t.Fatal("no tick received")
+	}
+
+	next := testTrack()
+	next.ID++
+	skipped := testTrack()
+	skipped.Skipped = true
+	source.tracks <- next
+	source.corrections <- skipped
+	source.stop()
+	dispatcher.Wait()
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.events) != 2 {
+		t.Fatalf("events = %d, want 2", len(recorder.events))
+	}
+	types := map[string]bool{}
+	for _, event := range recorder.events {
+		types[event.Type] = true
+	}
+	if !types[EventTrack] || !types[EventSkipped] {
+		t.Errorf("event types = %v, want track and skipped", types)
+	}
+	if !recorder.closed {
+		t.Error("sink not closed after the source stopped")
+	}
+}
@@ -0,0 +1,211 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"djtracker/internal/api/formatter"
+	"djtracker/internal/config"
+	"djtracker/internal/model"
+	"djtracker/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultWebhookAttempts = 5
+	webhookTimeout         = 10 * time.Second
+	// webhookBackoff délai avant la deuxième tentative, doublé à chaque nouvel échec
+	webhookBackoff    = time.Second
+	webhookMaxBackoff = time.Minute
+)
+
+// En-têtes ajoutés aux requêtes des webhooks
+const (
+	headerEvent     = "X-Trackker-Event"
+	headerSignature = "X-Trackker-Signature"
+)
+
+type webhookPayload struct {
+	Type   string              `json:"type"`
+	SentAt string              `json:"sent_at"`
+	Track  *formatter.TrackDTO `json:"track"`
+}
+
+// Webhook envoie les événements en POST à une URL, avec nouvelles tentatives et journal des envois
+type Webhook struct {
+	log         *slog.Logger
+	client      *http.Client
+	store       repository.DeliveryStore
+	url         string
+	events      []string
+	secret      []byte
+	template    *template.Template
+	contentType string
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewWebhook(log *slog.Logger, store repository.DeliveryStore, conf config.Webhook) (*Webhook, error) {
+	if conf.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+
+	webhook := &Webhook{
+		log:         log,
+		client:      &http.Client{Timeout: webhookTimeout},
+		store:       store,
+		url:         conf.URL,
+		events:      conf.Events,
+		secret:      []byte(conf.Secret),
+		contentType: conf.ContentType,
+		maxAttempts: conf.MaxAttempts,
+		backoff:     webhookBackoff,
+	}
+	if webhook.contentType == "" {
+		webhook.contentType = "application/json"
+	}
+	if webhook.maxAttempts <= 0 {
+		webhook.maxAttempts = defaultWebhookAttempts
+	}
+
+	if conf.Template != "" {
+		tmpl, err := template.New(conf.URL).Parse(conf.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for webhook %s: %w", conf.URL, err)
+		}
+		webhook.template = tmpl
+	}
+	return webhook, nil
+}
+
+func (w *Webhook) Name() string {
+	return "webhook " + w.url
+}
+
+func (w *Webhook) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// Send envoie l'événement s'il fait partie des types suivis, en réessayant avec un délai
+// croissant tant que le destinataire est injoignable ou répond une erreur 5xx ou 429
+func (w *Webhook) Send(ctx context.Context, event *Event) error {
+	if len(w.events) > 0 && !slices.Contains(w.events, event.Type) {
+		return nil
+	}
+
+	body, err := w.body(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		status, err := w.post(ctx, event.Type, body)
+		w.logDelivery(event, attempt, status, err)
+
+		if err == nil {
+			return nil
+		}
+		if attempt >= w.maxAttempts || !retryable(status) {
+			return fmt.Errorf("delivery failed after %d attempts: %w", attempt, err)
+		}
+
+		w.log.Warn("Webhook delivery failed, retrying", "url", w.url, "attempt", attempt, "retry_in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, webhookMaxBackoff)
+	}
+}
+
+func (w *Webhook) body(event *Event) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(&webhookPayload{
+			Type:   event.Type,
+			SentAt: event.Time.Format(time.RFC3339),
+			Track:  formatter.NewTrackDTO(event.Track),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("error rendering webhook template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// post envoie le corps signé, le code de statut vaut 0 si aucune réponse n'a été reçue
+func (w *Webhook) post(ctx context.Context, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	req.Header.Set("User-Agent", "trackker")
+	req.Header.Set(headerEvent, eventType)
+	if len(w.secret) > 0 {
+		req.Header.Set(headerSignature, "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (w *Webhook) logDelivery(event *Event, attempt, status int, err error) {
+	delivery := &model.WebhookDelivery{
+		Webhook:   w.url,
+		EventType: event.Type,
+		Attempt:   attempt,
+		Success:   err == nil,
+		CreatedAt: time.Now(),
+	}
+	if event.Track != nil && event.Track.ID != 0 {
+		delivery.TrackID = &event.Track.ID
+	}
+	if status != 0 {
+		delivery.StatusCode = &status
+	}
+	if err != nil {
+		message := err.Error()
+		delivery.Error = &message
+	}
+
+	if err := w.store.AddWebhookDelivery(delivery); err != nil {
+		w.log.Error("Failed to save webhook delivery", "url", w.url, "err", err)
+	}
+}
+
+// retryable indique si l'échec peut être temporaire : pas de réponse, erreur serveur ou limite de débit
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// Sign retourne la signature HMAC-SHA256 du corps en hexadécimal, à comparer par le destinataire
+// à l'en-tête X-Trackker-Signature (préfixé par « sha256= »)
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
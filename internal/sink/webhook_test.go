@@ -0,0 +1,187 @@
+package sink
+
+import (
+	"context"
+	"djtracker/internal/config"
+	"djtracker/internal/repository"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver répond successivement les codes de statuts puis 200
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mu.Unlock()
+
+	w.WriteHeader(status)
+}
+
+func (r *webhookReceiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func newTestWebhook(t *testing.T, store repository.DeliveryStore, conf config.Webhook) *Webhook {
+	t.Helper()
+	webhook, err := NewWebhook(discardLogger(), store, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhook.backoff = time.Millisecond
+	t.Cleanup(func() { _ = webhook.Close() })
+	return webhook
+}
+
+func TestWebhookSendRetriesAndSigns(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	store := repository.NewMemory()
+	webhook := newTestWebhook(t, store, config.Webhook{URL: server.URL, Secret: "s3cret"})
+
+	event := &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}
+	if err := webhook.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	requests := receiver.received()
+	if len(requests) != 3 {
+		t.Fatalf("received %d requests, want 3", len(requests))
+	}
+	last := requests[2]
+	if got, want := last.header.Get(headerSignature), "sha256="+Sign([]byte("s3cret"), last.body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+	if got := last.header.Get(headerEvent); got != EventTrack {
+		t.Errorf("%s = %q, want %q", headerEvent, got, EventTrack)
+	}
+	if got := last.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	var payload struct {
+		Type  string `json:"type"`
+		Track struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"track"`
+	}
+	if err := json.Unmarshal(last.body, &payload); err != nil {
+		t.Fatalf("invalid payload %s: %v", last.body, err)
+	}
+	if payload.Type != EventTrack || payload.Track.ID != 12 || payload.Track.Name != "One More Time" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	deliveries, _ := store.FindWebhookDeliveries(10)
+	if len(deliveries) != 3 {
+		t.Fatalf("logged %d deliveries, want 3", len(deliveries))
+	}
+	// Du plus récent au plus ancien
+	wantStatuses := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	for i, delivery := range deliveries {
+		if delivery.StatusCode == nil || *delivery.StatusCode != wantStatuses[i] || delivery.Attempt != 3-i {
+			t.Errorf("delivery %d = attempt %d status %v, want attempt %d status %d",
+				i, delivery.Attempt, delivery.StatusCode, 3-i, wantStatuses[i])
+		}
+		if delivery.Success != (i == 0) || delivery.TrackID == nil || *delivery.TrackID != 12 {
+			t.Errorf("delivery %d = %+v", i, delivery)
+		}
+	}
+}
+
+func TestWebhookSendStopsOnPermanentError(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	store := repository.NewMemory()
+	webhook := newTestWebhook(t, store, config.Webhook{URL: server.URL})
+
+	if err := webhook.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err == nil {
+		t.Fatal("Send succeeded on a 400 response")
+	}
+	if got := len(receiver.received()); got != 1 {
+		t.Errorf("received %d requests, want no retry", got)
+	}
+	if requests := receiver.received(); requests[0].header.Get(headerSignature) != "" {
+		t.Error("request signed without secret")
+	}
+	deliveries, _ := store.FindWebhookDeliveries(10)
+	if len(deliveries) != 1 || deliveries[0].Success || deliveries[0].Error == nil {
+		t.Errorf("deliveries = %+v, want one failed delivery", deliveries)
+	}
+}
+
+func TestWebhookSendGivesUpAfterMaxAttempts(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{500, 500, 500, 500}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	webhook := newTestWebhook(t, repository.NewMemory(), config.Webhook{URL: server.URL, MaxAttempts: 2})
+	if err := webhook.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err == nil {
+		t.Fatal("Send succeeded while the receiver kept failing")
+	}
+	if got := len(receiver.received()); got != 2 {
+		t.Errorf("received %d requests, want 2", got)
+	}
+}
+
+func TestWebhookSendFiltersEventsAndRendersTemplate(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	webhook := newTestWebhook(t, repository.NewMemory(), config.Webhook{
+		URL:         server.URL,
+		Events:      []string{EventSkipped},
+		Template:    `{{.Type}}: {{.Track.Name}}`,
+		ContentType: "text/plain",
+	})
+
+	if err := webhook.Send(context.Background(), &Event{Type: EventTrack, Track: testTrack(), Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(receiver.received()); got != 0 {
+		t.Fatalf("received %d requests for a filtered event type", got)
+	}
+
+	if err := webhook.Send(context.Background(), &Event{Type: EventSkipped, Track: testTrack(), Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("received %d requests, want 1", len(requests))
+	}
+	if got := string(requests[0].body); got != "skipped: One More Time" {
+		t.Errorf("body = %q", got)
+	}
+	if got := requests[0].header.Get("Content-Type"); got != "text/plain" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+)
+
+// GetTrackBPM lit le tempo dans les tags du fichier (ID3v2, MP4 ou Vorbis), 0 s'il est absent
+func GetTrackBPM(metadata tag.Metadata) float64 {
+	if metadata == nil {
+		return 0
+	}
+
+	raw := metadata.Raw()
+	for _, key := range []string{"TBPM", "TBP", "tmpo", "bpm"} {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case string:
+			if bpm, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return bpm
+			}
+		case []byte:
+			if len(v) == 2 {
+				return float64(binary.BigEndian.Uint16(v))
+			}
+		}
+	}
+	return 0
+}
+
+// GetTrackDuration calcule la durée du fichier audio à partir de ses en-têtes.
+// Seuls les formats MP3, FLAC et WAV sont pris en charge, 0 sinon : un fichier n'est lu comme MP3
+// que s'il commence par un tag ID3v2 ou une frame valide, ou porte l'extension .mp3.
+func GetTrackDuration(path string) time.Duration {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer SafeClose(file)
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0
+	}
+
+	var duration time.Duration
+	switch {
+	case string(header) == "fLaC":
+		duration, err = flacDuration(file)
+	case string(header) == "RIFF":
+		duration, err = wavDuration(file)
+	case string(header[:3]) == "ID3" || isMP3Frame(header) || strings.EqualFold(filepath.Ext(path), ".mp3"):
+		duration, err = mp3Duration(file, stat.Size())
+	default:
+		return 0
+	}
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// flacDuration lit le bloc STREAMINFO qui suit toujours la signature "fLaC"
+func flacDuration(r io.ReadSeeker) (time.Duration, error) {
+	buf := make([]byte, 4+4+18)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, err
+	}
+
+	info := buf[8:]
+	sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+	totalSamples := uint64(info[13]&0x0f)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+	if sampleRate == 0 {
+		return 0, fmt.Errorf("invalid flac sample rate")
+	}
+	return time.Duration(totalSamples * uint64(time.Second) / sampleRate), nil
+}
+
+// wavFormatSize taille lue du chunk "fmt " : le débit se trouve dans les 16 premiers octets,
+// communs à tous les formats, les extensions éventuelles sont ignorées
+const wavFormatSize = 16
+
+// wavDuration parcourt les chunks RIFF jusqu'à trouver "fmt " et "data"
+func wavDuration(r io.ReadSeeker) (time.Duration, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var byteRate uint32
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return 0, err
+		}
+		id := string(chunk[:4])
+		size := binary.LittleEndian.Uint32(chunk[4:])
+
+		switch id {
+		case "fmt ":
+			if size < wavFormatSize {
+				return 0, fmt.Errorf("invalid wav format chunk")
+			}
+			format := make([]byte, wavFormatSize)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+			if _, err := r.Seek(int64(size)-wavFormatSize+int64(size%2), io.SeekCurrent); err != nil {
+				return 0, err
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0, fmt.Errorf("wav data chunk before format chunk")
+			}
+			return time.Duration(uint64(size) * uint64(time.Second) / uint64(byteRate)), nil
+		default:
+			if _, err := r.Seek(int64(size)+int64(size%2), io.SeekCurrent); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+var (
+	mp3Bitrates = [2][3][16]int{
+		// MPEG 1 : layers I, II, III
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		},
+		// MPEG 2 et 2.5 : layers I, II, III
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		},
+	}
+	mp3SampleRates = map[byte][3]int{
+		3: {44100, 48000, 32000}, // MPEG 1
+		2: {22050, 24000, 16000}, // MPEG 2
+		0: {11025, 12000, 8000},  // MPEG 2.5
+	}
+)
+
+// mp3Duration utilise l'en-tête Xing/Info ou VBRI de la première frame s'il existe,
+// sinon estime la durée à partir du débit de la première frame (CBR)
+func mp3Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	offset, err := skipID3v2(r)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if !isMP3Frame(buf[i:]) {
+			continue
+		}
+
+		version := (buf[i+1] >> 3) & 0x03
+		layer := (buf[i+1] >> 1) & 0x03
+		bitrateIndex := buf[i+2] >> 4
+		sampleRateIndex := (buf[i+2] >> 2) & 0x03
+		channelMode := buf[i+3] >> 6
+
+		versionRow := 0
+		if version != 3 {
+			versionRow = 1
+		}
+		bitrate := mp3Bitrates[versionRow][3-layer][bitrateIndex] * 1000
+		sampleRate := mp3SampleRates[version][sampleRateIndex]
+
+		samplesPerFrame := 1152
+		if layer == 3 {
+			samplesPerFrame = 384
+		} else if layer == 1 && version != 3 {
+			samplesPerFrame = 576
+		}
+
+		if frames := mp3VbrFrames(buf[i:], version, channelMode); frames > 0 {
+			seconds := float64(frames) * float64(samplesPerFrame) / float64(sampleRate)
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+
+		// Calcul en flottant : audioSize * 8 * time.Second dépasse un int64 au-delà d'environ 1,15 Go
+		audioSize := size - offset - int64(i)
+		return time.Duration(float64(audioSize*8) * float64(time.Second) / float64(bitrate)), nil
+	}
+	return 0, fmt.Errorf("no mp3 frame found")
+}
+
+// isMP3Frame indique si les 4 premiers octets forment un en-tête de frame MPEG audio valide
+func isMP3Frame(b []byte) bool {
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return false
+	}
+	version := (b[1] >> 3) & 0x03
+	layer := (b[1] >> 1) & 0x03
+	bitrateIndex := b[2] >> 4
+	sampleRateIndex := (b[2] >> 2) & 0x03
+	return version != 1 && layer != 0 && bitrateIndex != 0 && bitrateIndex != 15 && sampleRateIndex != 3
+}
+
+// mp3VbrFrames retourne le nombre de frames annoncé par l'en-tête Xing/Info ou VBRI, 0 s'il n'y en a pas
+func mp3VbrFrames(frame []byte, version, channelMode byte) uint32 {
+	sideInfo := 32
+	if version == 3 && channelMode == 3 {
+		sideInfo = 17
+	} else if version != 3 && channelMode != 3 {
+		sideInfo = 17
+	} else if version != 3 {
+		sideInfo = 9
+	}
+
+	xing := 4 + sideInfo
+	if len(frame) >= xing+12 {
+		id := frame[xing : xing+4]
+		if bytes.Equal(id, []byte("Xing")) || bytes.Equal(id, []byte("Info")) {
+			flags := binary.BigEndian.Uint32(frame[xing+4 : xing+8])
+			if flags&0x01 != 0 {
+				return binary.BigEndian.Uint32(frame[xing+8 : xing+12])
+			}
+		}
+	}
+
+	vbri := 4 + 32
+	if len(frame) >= vbri+18 && bytes.Equal(frame[vbri:vbri+4], []byte("VBRI")) {
+		return binary.BigEndian.Uint32(frame[vbri+14 : vbri+18])
+	}
+	return 0
+}
+
+// skipID3v2 positionne le lecteur après l'éventuel tag ID3v2 et retourne sa taille
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+
+	if string(header[:3]) != "ID3" {
+		_, err := r.Seek(0, io.SeekStart)
+		return 0, err
+	}
+
+	size := int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 | int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f)
+	size += 10
+	if header[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	_, err := r.Seek(size, io.SeekStart)
+	return size, err
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetTrackDuration(t *testing.T) {
+	tests := []struct {
+		file string
+		want time.Duration
+	}{
+		// fmt étendu et chunk LIST de taille impaire avant les données
+		{"tone.wav", 2 * time.Second},
+		// Chunk fmt annonçant 4 Go : ignoré sans être alloué
+		{"huge-fmt.wav", 0},
+		{"streaminfo.flac", 3 * time.Second},
+		{"cbr.mp3", time.Second},
+		{"vbr.mp3", 2612 * time.Millisecond},
+		// Motif de synchronisation MPEG au milieu d'un fichier qui n'est pas un MP3
+		{"archive.zip", 0},
+		{"notes.txt", 0},
+		{"missing.mp3", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := GetTrackDuration(filepath.Join("testdata", tt.file)).Truncate(time.Millisecond)
+			if got != tt.want {
+				t.Errorf("GetTrackDuration(%s) = %s, want %s", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+// Fichier CBR de plusieurs Go (mix enregistré) : la durée ne doit pas déborder
+func TestMP3DurationLargeFile(t *testing.T) {
+	tests := []struct {
+		size int64
+		want time.Duration
+	}{
+		{1 << 20, 65536 * time.Millisecond},
+		{2 << 30, 134217728 * time.Millisecond},
+		{5 << 30, 335544320 * time.Millisecond},
+	}
+
+	// Frame MPEG 1 layer III à 128 kbit/s, la taille annoncée du fichier donne la durée
+	frame := append([]byte{0xff, 0xfb, 0x90, 0x00}, make([]byte, 60)...)
+	for _, tt := range tests {
+		got, err := mp3Duration(bytes.NewReader(frame), tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("mp3Duration(size %d) = %s, want %s", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestIsMP3Frame(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   bool
+	}{
+		{"mpeg 1 layer III", []byte{0xff, 0xfb, 0x90, 0x00}, true},
+		{"mpeg 2 layer III", []byte{0xff, 0xf3, 0x80, 0xc4}, true},
+		{"reserved version", []byte{0xff, 0xeb, 0x90, 0x00}, false},
+		{"free bitrate", []byte{0xff, 0xfb, 0x00, 0x00}, false},
+		{"reserved sample rate", []byte{0xff, 0xfb, 0x9c, 0x00}, false},
+		{"no sync", []byte("RIFF"), false},
+		{"too short", []byte{0xff, 0xfb}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMP3Frame(tt.header); got != tt.want {
+				t.Errorf("isMP3Frame(% x) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
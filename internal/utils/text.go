@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldDiacritics retire les accents et met le texte en minuscules ("Été" devient "ete")
+func FoldDiacritics(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// Words découpe le texte en mots composés uniquement de lettres et de chiffres
+func Words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchText retourne les mots des valeurs sans accents ni majuscules, séparés par des espaces,
+// tels que comparés aux termes d'une recherche
+func SearchText(values ...string) string {
+	return strings.Join(Words(FoldDiacritics(strings.Join(values, " "))), " ")
+}
@@ -15,6 +15,15 @@ func EmptyStringNil(s string) *string {
 	return &s
 }
 
+// ZeroNil retourne nil pour la valeur zéro du type, un pointeur vers la valeur sinon
+func ZeroNil[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
 func Exists(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {